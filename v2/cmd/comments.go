@@ -0,0 +1,56 @@
+package root
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"go-diff/internal/review"
+)
+
+var commentsOutput string
+
+var commentsCmd = &cobra.Command{
+	Use:   "comments",
+	Short: "Work with review comments",
+}
+
+var exportCommentsCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print review comments as markdown",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		store, err := review.Open(cfg.CommentStorage)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		comments, err := store.Load()
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+
+		var out io.Writer = os.Stdout
+		if commentsOutput != "" {
+			f, err := os.Create(commentsOutput)
+			if err != nil {
+				fmt.Println("error : ", err)
+				os.Exit(1)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := review.WriteMarkdown(out, comments); err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,25 @@
+package root
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Print the effective configuration and where each value comes from",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		if err := cfg.Write(os.Stdout); err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+	},
+}
@@ -0,0 +1,114 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"go-diff/internal/export"
+	"go-diff/internal/git"
+	"go-diff/internal/models"
+	"go-diff/internal/parser"
+	"go-diff/internal/theme"
+)
+
+var exportHTML string
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Render the diff to a file that can be read without a terminal",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if exportHTML == "" {
+			fmt.Println("error : ", errors.New("nothing to export to; pass --html FILE"))
+			os.Exit(1)
+		}
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		// The page is not tied to the terminal's background.
+		t, err := theme.Resolve(cfg.Theme, cfg.Themes, true)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		opts := cfg.DiffOptions(cached)
+		files, err := loadDiff(opts)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+
+		f, err := os.Create(exportHTML)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		err = export.WriteHTML(f, files, export.HTMLOptions{
+			Title: diffTitle(opts),
+			Split: cfg.ViewMode == "split",
+			Theme: t,
+			Contents: func(path string) (string, string) {
+				return git.GetFileContents(opts.Cached, path)
+			},
+		})
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// printFormat prints the parsed diff to w as json or ndjson. A diff piped
+// in is printed instead of the work tree's.
+func printFormat(w io.Writer, opts git.Options, format string, in *pipedInput) error {
+	if format != "json" && format != "ndjson" {
+		return fmt.Errorf("unknown format %q (want tui, json or ndjson)", format)
+	}
+	var files []models.DiffFile
+	if in != nil {
+		files = parser.ParseGitDiff(in.text)
+	} else {
+		var err error
+		if files, err = loadDiff(opts); err != nil {
+			return err
+		}
+	}
+	if format == "ndjson" {
+		return export.WriteNDJSON(w, files)
+	}
+	return export.WriteJSON(w, files)
+}
+
+// loadDiff runs git diff and parses its output, dropping files whose
+// changes were all ignored by the whitespace options.
+func loadDiff(opts git.Options) ([]models.DiffFile, error) {
+	raw, err := git.GetDiff(opts)
+	if err != nil {
+		return nil, err
+	}
+	files := parser.ParseGitDiff(raw)
+	if opts.Whitespace == (git.Whitespace{}) {
+		return files, nil
+	}
+	return parser.DropEmpty(files), nil
+}
+
+// diffTitle names the diff after the repository and what it compares.
+func diffTitle(opts git.Options) string {
+	name := "go-diff"
+	if root, err := git.RepoRoot(); err == nil {
+		name = filepath.Base(root)
+	}
+	if opts.Cached {
+		return name + ": staged changes"
+	}
+	return name + ": unstaged changes"
+}
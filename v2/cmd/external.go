@@ -0,0 +1,238 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"go-diff/internal/batch"
+	"go-diff/internal/git"
+	"go-diff/internal/parser"
+	"go-diff/internal/ui"
+)
+
+var batchMode bool
+
+var externalDiffCmd = &cobra.Command{
+	Use:   "external-diff PATH OLD-FILE OLD-HEX OLD-MODE NEW-FILE NEW-HEX NEW-MODE",
+	Short: "View a file pair handed over by git as its GIT_EXTERNAL_DIFF",
+	Long: `View a file pair handed over by git as its GIT_EXTERNAL_DIFF, for example
+
+  GIT_EXTERNAL_DIFF='go-diff external-diff --batch' git --no-pager diff
+
+git runs the command once per file. With --batch every file of the run is
+shown in one session when the last one arrives.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		// Unmerged paths come with the path alone; renames add the new path
+		// and a similarity line.
+		if n := len(args); n != 1 && n != 7 && n != 9 {
+			return fmt.Errorf("accepts 1, 7 or 9 arg(s), received %d", n)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			fmt.Printf("* Unmerged path %s\n", args[0])
+			return
+		}
+		c := comparison{old: args[1], new: args[4], oldName: args[0], name: args[0]}
+		if len(args) == 9 {
+			c.name = args[7]
+		}
+		if err := runComparisons(cmd, []comparison{c}); err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var difftoolCmd = &cobra.Command{
+	Use:   "difftool LOCAL REMOTE",
+	Short: "View two files or directories as git difftool's tool",
+	Long: `View two files or directories as git difftool's tool. Set it up with
+
+  git config difftool.go-diff.cmd 'go-diff difftool --batch "$LOCAL" "$REMOTE"'
+  git config difftool.prompt false
+
+and run git difftool -t go-diff. With -d, git hands over two directories,
+which are shown in one session; without it, --batch gathers the files git
+hands over one at a time.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		list, err := difftoolComparisons(args[0], args[1])
+		if err == nil {
+			err = runComparisons(cmd, list)
+		}
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// comparison is a pair of files to diff and the names to show them under.
+type comparison struct {
+	old, new      string
+	oldName, name string
+}
+
+// difftoolComparisons pairs up local and remote. Two directories are
+// compared file by file; a single pair is named after $MERGED, which git
+// sets to the path in the repository.
+func difftoolComparisons(local, remote string) ([]comparison, error) {
+	localInfo, err := os.Stat(local)
+	if err != nil {
+		return nil, err
+	}
+	remoteInfo, err := os.Stat(remote)
+	if err != nil {
+		return nil, err
+	}
+	if localInfo.IsDir() != remoteInfo.IsDir() {
+		return nil, errors.New("cannot compare a file with a directory")
+	}
+	if !localInfo.IsDir() {
+		name := os.Getenv("MERGED")
+		if name == "" {
+			name = filepath.Base(remote)
+		}
+		return []comparison{{old: local, new: remote, oldName: name, name: name}}, nil
+	}
+
+	paths := map[string]bool{}
+	for _, dir := range []string{local, remote} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			paths[filepath.ToSlash(rel)] = true
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	var list []comparison
+	for path := range paths {
+		list = append(list, comparison{
+			old:     sidePath(local, path),
+			new:     sidePath(remote, path),
+			oldName: path,
+			name:    path,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list, nil
+}
+
+// sidePath is path within dir, or /dev/null when it is not there.
+func sidePath(dir, path string) string {
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	if _, err := os.Stat(full); err != nil {
+		return os.DevNull
+	}
+	return full
+}
+
+// runComparisons diffs list and shows the result, or with --batch saves it
+// until the last file of git's run comes in.
+func runComparisons(cmd *cobra.Command, list []comparison) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+	pair, err := compare(cfg.DiffOptions(false), list)
+	if err != nil {
+		return err
+	}
+	if counter, total, ok := batch.Position(); batchMode && ok {
+		pairs, err := batch.Add(pair, counter, total)
+		if err != nil || pairs == nil {
+			return err
+		}
+		pair = batch.Pair{Old: map[string]string{}, New: map[string]string{}}
+		for _, p := range pairs {
+			pair.Files = append(pair.Files, p.Files...)
+			for path, content := range p.Old {
+				pair.Old[path] = content
+			}
+			for path, content := range p.New {
+				pair.New[path] = content
+			}
+		}
+	}
+	if len(pair.Files) == 0 {
+		return nil
+	}
+
+	contents := func(path string) (string, string) {
+		return pair.Old[path], pair.New[path]
+	}
+	if !interactive() {
+		opts, err := printOptions(cfg)
+		if err != nil {
+			return err
+		}
+		opts.Contents = contents
+		return ui.Print(os.Stdout, pair.Files, opts)
+	}
+	// git does not always hand its terminal on to the tool as stdin.
+	return runViewer(cfg, ui.Options{
+		Diff:     cfg.DiffOptions(false),
+		Input:    pair.Files,
+		Contents: contents,
+	}, true)
+}
+
+// compare diffs each comparison and reads both sides, which git may delete
+// as soon as this process exits.
+func compare(opts git.Options, list []comparison) (batch.Pair, error) {
+	pair := batch.Pair{Old: map[string]string{}, New: map[string]string{}}
+	for _, c := range list {
+		// difftool -d links work tree files into its directories; git diff
+		// would compare the links rather than the files.
+		old, err := resolve(c.old)
+		if err != nil {
+			return pair, err
+		}
+		new, err := resolve(c.new)
+		if err != nil {
+			return pair, err
+		}
+		raw, err := git.DiffFiles(opts, old, new)
+		if err != nil {
+			return pair, err
+		}
+		for _, f := range parser.ParseGitDiff(raw) {
+			f.OldName, f.FileName = c.oldName, c.name
+			pair.Files = append(pair.Files, f)
+		}
+		pair.Old[c.name] = readSide(old)
+		pair.New[c.name] = readSide(new)
+	}
+	return pair, nil
+}
+
+func resolve(path string) (string, error) {
+	if path == os.DevNull {
+		return path, nil
+	}
+	return filepath.EvalSymlinks(path)
+}
+
+func readSide(path string) string {
+	if path == os.DevNull {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,60 @@
+package root
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"go-diff/internal/parser"
+)
+
+// pipedInput is text piped in on stdin, as when go-diff is git's
+// core.pager or interactive.diffFilter.
+type pipedInput struct {
+	raw  []byte // as received, possibly colored by git
+	text string // with colors stripped
+}
+
+// readPiped reads stdin if something is piped in, or returns nil.
+func readPiped() (*pipedInput, error) {
+	if !piped(os.Stdin) {
+		return nil, nil
+	}
+	raw, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return &pipedInput{raw: raw, text: parser.StripANSI(string(raw))}, nil
+}
+
+// isDiff reports whether the input holds at least one file diff. git log
+// without -p, git branch and friends go through the pager too.
+func (in *pipedInput) isDiff() bool {
+	for _, line := range strings.Split(in.text, "\n") {
+		if strings.HasPrefix(line, "diff --git") {
+			return true
+		}
+	}
+	return false
+}
+
+// page shows input that is not a diff with less, or copies it to stdout
+// when less is not installed.
+func page(raw []byte) error {
+	cmd := exec.Command("less", "-R")
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	cmd.Stdin = bytes.NewReader(raw)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err == nil {
+		return nil
+	} else if _, ok := err.(*exec.Error); !ok {
+		return err
+	}
+	_, err := os.Stdout.Write(raw)
+	return err
+}
@@ -0,0 +1,123 @@
+package root
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+
+	"go-diff/internal/config"
+	"go-diff/internal/git"
+	"go-diff/internal/models"
+	"go-diff/internal/parser"
+	"go-diff/internal/theme"
+	"go-diff/internal/ui"
+)
+
+// defaultPrintWidth is used when printing to something that is not a
+// terminal and no --width was given.
+const defaultPrintWidth = 120
+
+var (
+	printMode   bool
+	printWidth  int
+	lineNumbers bool
+	colorMode   string
+)
+
+// interactive reports whether the TUI can run: stdout has to be a terminal.
+func interactive() bool {
+	return term.IsTerminal(os.Stdout.Fd())
+}
+
+// printOptions resolves the color, width and theme of printed output.
+func printOptions(cfg *config.Loaded) (ui.PrintOptions, error) {
+	switch colorMode {
+	case "always":
+		lipgloss.SetColorProfile(termenv.TrueColor)
+	case "never":
+		lipgloss.SetColorProfile(termenv.Ascii)
+	case "auto":
+	default:
+		return ui.PrintOptions{}, fmt.Errorf("unknown color mode %q (want auto, always or never)", colorMode)
+	}
+
+	width := printWidth
+	if width <= 0 {
+		width = defaultPrintWidth
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			width = w
+		}
+	}
+	// The page background is unknown once the output leaves the terminal.
+	dark := true
+	if interactive() {
+		dark = lipgloss.HasDarkBackground()
+	}
+	t, err := theme.Resolve(cfg.Theme, cfg.Themes, dark)
+	if err != nil {
+		return ui.PrintOptions{}, err
+	}
+	return ui.PrintOptions{
+		Split:            cfg.ViewMode == "split",
+		Width:            width,
+		LineNumbers:      lineNumbers,
+		Theme:            t,
+		MovedIgnoreSpace: cfg.MovedIgnoreSpace,
+	}, nil
+}
+
+// runPrint renders the diff straight to stdout. A diff piped in is printed
+// instead of the work tree's; other piped text is passed through.
+func runPrint(cfg *config.Loaded, in *pipedInput) error {
+	opts, err := printOptions(cfg)
+	if err != nil {
+		return err
+	}
+	var files []models.DiffFile
+	if in != nil && !in.isDiff() {
+		_, err := os.Stdout.Write(in.raw)
+		return err
+	} else if in != nil {
+		files = parser.ParseGitDiff(in.text)
+	} else {
+		diffOpts := cfg.DiffOptions(cached)
+		if files, err = loadDiff(diffOpts); err != nil {
+			return err
+		}
+		opts.Contents = func(path string) (string, string) {
+			return git.GetFileContents(diffOpts.Cached, path)
+		}
+	}
+	return ui.Print(os.Stdout, files, opts)
+}
+
+// runDiffFilter recolors the diff git add -p pipes in. Git shows the result
+// on the terminal, so colors follow the terminal on stderr rather than the
+// pipe on stdout.
+func runDiffFilter(cfg *config.Loaded, in *pipedInput) error {
+	if in == nil {
+		return errors.New("--diff-filter reads a diff on stdin")
+	}
+	if colorMode == "auto" {
+		lipgloss.SetColorProfile(termenv.NewOutput(os.Stderr).ColorProfile())
+	}
+	opts, err := printOptions(cfg)
+	if err != nil {
+		return err
+	}
+	return ui.Recolor(os.Stdout, in.text, opts)
+}
+
+// piped reports whether f is a pipe or a redirected file rather than a
+// terminal or /dev/null.
+func piped(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
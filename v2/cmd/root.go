@@ -2,33 +2,233 @@ package root
 
 import (
 	"fmt"
+	"go-diff/internal/config"
+	"go-diff/internal/export"
+	"go-diff/internal/git"
+	"go-diff/internal/keymap"
+	"go-diff/internal/parser"
+	"go-diff/internal/review"
+	"go-diff/internal/theme"
 	"go-diff/internal/ui"
+	"go-diff/internal/watch"
 	"os"
+	"strconv"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
 
-var cached bool
+var (
+	cached       bool
+	themeName    string
+	contextLines int
+	viewMode     string
+	ignorePaths  []string
+	watchTree    bool
+	whitespace   git.Whitespace
+	algorithm    string
+	findRenames  int
+	findCopies   int
+	movedSpace   bool
+	outputFormat string
+	diffFilter   bool
+)
 
 var rootCmd = &cobra.Command{
-	Use: "go-diff",
+	Use:   "go-diff",
 	Short: "View Git diff in terminal ui",
-	Run: func(cmd *cobra.Command, args []string){
-		m := ui.NewModel(cached)
-		p := tea.NewProgram(m)
-		
-		if _, err := p.Run(); err != nil {
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		in, err := readPiped()
+		if err != nil {
+			fmt.Println("error : ", err)
+			os.Exit(1)
+		}
+		if diffFilter {
+			if err := runDiffFilter(cfg, in); err != nil {
+				fmt.Println("error : ", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if outputFormat != "tui" {
+			if err := printFormat(os.Stdout, cfg.DiffOptions(cached), outputFormat, in); err != nil {
+				fmt.Println("error : ", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if printMode || !interactive() {
+			if err := runPrint(cfg, in); err != nil {
+				fmt.Println("error : ", err)
+				os.Exit(1)
+			}
+			return
+		}
+		// As core.pager, anything that is not a diff is paged as it is.
+		if in != nil && !in.isDiff() {
+			if err := page(in.raw); err != nil {
+				fmt.Println("error : ", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		opts := ui.Options{Diff: cfg.DiffOptions(cached)}
+		if in != nil {
+			opts.Input = parser.ParseGitDiff(in.text)
+		}
+		if watchTree && in == nil {
+			w, err := watch.New()
+			if err != nil {
+				fmt.Println("error : ", err)
+				os.Exit(1)
+			}
+			defer w.Close()
+			opts.Watcher = w
+		}
+		// When stdin was the diff, keys come from the terminal.
+		if err := runViewer(cfg, opts, in != nil); err != nil {
 			fmt.Println("error : ", err)
 			os.Exit(1)
 		}
 	},
 }
 
+// runViewer fills in the settings of opts that come from cfg and runs the
+// TUI until it quits.
+func runViewer(cfg *config.Loaded, opts ui.Options, ttyInput bool) error {
+	keys, err := keymap.New(cfg.Keys)
+	if err != nil {
+		return err
+	}
+	t, err := theme.Resolve(cfg.Theme, cfg.Themes, lipgloss.HasDarkBackground())
+	if err != nil {
+		return err
+	}
+	comments, err := review.Open(cfg.CommentStorage)
+	if err != nil {
+		return err
+	}
+	viewed, err := review.OpenViewed()
+	if err != nil {
+		return err
+	}
+
+	opts.Split = cfg.ViewMode == "split"
+	opts.Keys = keys
+	opts.Theme = t
+	opts.Comments = comments
+	opts.Viewed = viewed
+	opts.MovedIgnoreSpace = cfg.MovedIgnoreSpace
+
+	programOpts := []tea.ProgramOption{tea.WithAltScreen(), tea.WithMouseCellMotion()}
+	if ttyInput {
+		programOpts = append(programOpts, tea.WithInputTTY())
+	}
+	_, err = tea.NewProgram(ui.NewModel(opts), programOpts...).Run()
+	return err
+}
+
+// loadConfig merges the config files and applies the flags set on cmd.
+func loadConfig(cmd *cobra.Command) (*config.Loaded, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("unified") {
+		cfg.ContextLines = contextLines
+		cfg.SetFlag("context_lines", "unified")
+	}
+	if flags.Changed("view") {
+		cfg.ViewMode = viewMode
+		cfg.SetFlag("view_mode", "view")
+	}
+	if flags.Changed("theme") {
+		cfg.Theme = themeName
+		cfg.SetFlag("theme", "theme")
+	}
+	if flags.Changed("diff-algorithm") {
+		cfg.Algorithm = algorithm
+		cfg.SetFlag("algorithm", "diff-algorithm")
+	}
+	if flags.Changed("find-renames") {
+		cfg.FindRenames = findRenames
+		cfg.SetFlag("find_renames", "find-renames")
+	}
+	if flags.Changed("find-copies") {
+		cfg.FindCopies = findCopies
+		cfg.SetFlag("find_copies", "find-copies")
+	}
+	if flags.Changed("moved-ignore-space") {
+		cfg.MovedIgnoreSpace = movedSpace
+		cfg.SetFlag("moved_ignore_space", "moved-ignore-space")
+	}
+	if flags.Changed("ignore") {
+		cfg.IgnorePaths = ignorePaths
+		cfg.SetFlag("ignore_paths", "ignore")
+	}
+	for _, f := range []struct {
+		flag, key string
+		value     bool
+		set       *bool
+	}{
+		{"ignore-all-space", "whitespace.ignore_all_space", whitespace.IgnoreAllSpace, &cfg.Whitespace.IgnoreAllSpace},
+		{"ignore-space-change", "whitespace.ignore_space_change", whitespace.IgnoreSpaceChange, &cfg.Whitespace.IgnoreSpaceChange},
+		{"ignore-blank-lines", "whitespace.ignore_blank_lines", whitespace.IgnoreBlankLines, &cfg.Whitespace.IgnoreBlankLines},
+		{"ignore-cr-at-eol", "whitespace.ignore_cr_at_eol", whitespace.IgnoreCRAtEOL, &cfg.Whitespace.IgnoreCRAtEOL},
+	} {
+		if flags.Changed(f.flag) {
+			*f.set = f.value
+			cfg.SetFlag(f.key, f.flag)
+		}
+	}
+	return cfg, cfg.Validate()
+}
+
 func Execute() {
 	rootCmd.Flags().BoolVarP(&cached, "ccched", "c", false, "Show staged diff (--cached)")
+	rootCmd.Flags().BoolVarP(&watchTree, "watch", "w", false, "Refresh the diff whenever the work tree or index changes")
+	rootCmd.Flags().BoolVar(&printMode, "print", false, "Print the styled diff to stdout instead of starting the TUI (the default when stdout is not a terminal)")
+	rootCmd.Flags().IntVar(&printWidth, "width", 0, "Width to print at (defaults to the terminal's, or 120)")
+	rootCmd.Flags().BoolVar(&lineNumbers, "line-numbers", false, "Show line numbers in the printed unified view")
+	rootCmd.Flags().StringVar(&colorMode, "color", "auto", "Color printed output: auto, always or never")
+	rootCmd.Flags().BoolVar(&diffFilter, "diff-filter", false, "Recolor a diff on stdin line for line, for git's interactive.diffFilter")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "tui", "Output format: tui, or json/ndjson to print the parsed diff (schema version "+strconv.Itoa(export.SchemaVersion)+")")
+
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&themeName, "theme", "auto", "Color theme: auto, dark, light, solarized, high-contrast or one defined in the config")
+	flags.IntVarP(&contextLines, "unified", "U", 3, "Lines of context around each change")
+	flags.StringVar(&viewMode, "view", "unified", "Initial view: unified or split")
+	flags.BoolVar(&whitespace.IgnoreAllSpace, "ignore-all-space", false, "Ignore whitespace when comparing lines")
+	flags.BoolVar(&whitespace.IgnoreSpaceChange, "ignore-space-change", false, "Ignore changes in amount of whitespace")
+	flags.BoolVar(&whitespace.IgnoreBlankLines, "ignore-blank-lines", false, "Ignore changes whose lines are all blank")
+	flags.BoolVar(&whitespace.IgnoreCRAtEOL, "ignore-cr-at-eol", false, "Ignore carriage-return at the end of line")
+	flags.StringVar(&algorithm, "diff-algorithm", "", "Diff algorithm: myers, minimal, patience or histogram")
+	flags.IntVar(&findRenames, "find-renames", 0, "Similarity percentage for rename detection (0 keeps git's default)")
+	flags.IntVar(&findCopies, "find-copies", 0, "Similarity percentage for copy detection (0 turns it off)")
+	flags.BoolVar(&movedSpace, "moved-ignore-space", false, "Detect moved code even when its whitespace changed")
+	flags.StringArrayVar(&ignorePaths, "ignore", nil, "Leave paths matching this pathspec out of the diff (repeatable)")
+
+	rootCmd.AddCommand(configCmd)
+	exportCmd.Flags().StringVar(&exportHTML, "html", "", "Write a self-contained HTML page to this file")
+	exportCmd.Flags().BoolVarP(&cached, "cached", "c", false, "Export the staged diff")
+	rootCmd.AddCommand(exportCmd)
+	externalDiffCmd.Flags().BoolVar(&batchMode, "batch", false, "Gather every file of one git run into a single session")
+	difftoolCmd.Flags().BoolVar(&batchMode, "batch", false, "Gather every file of one git difftool run into a single session")
+	rootCmd.AddCommand(externalDiffCmd, difftoolCmd)
+	exportCommentsCmd.Flags().StringVarP(&commentsOutput, "output", "o", "", "Write to this file instead of stdout")
+	commentsCmd.AddCommand(exportCommentsCmd)
+	rootCmd.AddCommand(commentsCmd)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,86 @@
+// Package align lines up the removed and added lines of a diff, for side by
+// side views and intra-line highlights.
+package align
+
+import "go-diff/internal/models"
+
+// Row is one aligned row of a split view, holding indexes into the hunk's
+// lines. A side of -1 is a filler row.
+type Row struct {
+	Left  int
+	Right int
+}
+
+// Rows aligns the lines of a hunk so that each run of removed lines sits
+// next to the run of added lines that replaced it.
+func Rows(h models.DiffHunk) []Row {
+	var rows []Row
+	var removed, added []int
+
+	flush := func() {
+		for i := 0; i < max(len(removed), len(added)); i++ {
+			row := Row{Left: -1, Right: -1}
+			if i < len(removed) {
+				row.Left = removed[i]
+			}
+			if i < len(added) {
+				row.Right = added[i]
+			}
+			rows = append(rows, row)
+		}
+		removed, added = removed[:0], added[:0]
+	}
+
+	for i, line := range h.Lines {
+		switch line.Type {
+		case "-":
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, i)
+		case "+":
+			added = append(added, i)
+		default:
+			flush()
+			if line.OldNum == 0 && line.NewNum == 0 {
+				// "\ No newline at end of file" and friends belong to
+				// whichever side came right before them.
+				continue
+			}
+			rows = append(rows, Row{Left: i, Right: i})
+		}
+	}
+	flush()
+	return rows
+}
+
+// Pairs pairs up removed and added lines that sit next to each other in
+// the split view, per hunk.
+func Pairs(f models.DiffFile) []map[int]int {
+	pairs := make([]map[int]int, len(f.Hunks))
+	for hi, h := range f.Hunks {
+		pairs[hi] = map[int]int{}
+		for _, row := range Rows(h) {
+			if row.Left >= 0 && row.Right >= 0 && row.Left != row.Right {
+				pairs[hi][row.Left] = row.Right
+				pairs[hi][row.Right] = row.Left
+			}
+		}
+	}
+	return pairs
+}
+
+// ChangedSpan returns the byte range of a that differs from b once their
+// common prefix and suffix are set aside.
+func ChangedSpan(a, b string) (int, int) {
+	ra, rb := []rune(a), []rune(b)
+	prefix := 0
+	for prefix < len(ra) && prefix < len(rb) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(ra)-prefix && suffix < len(rb)-prefix && ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+	return len(string(ra[:prefix])), len(a) - len(string(ra[len(ra)-suffix:]))
+}
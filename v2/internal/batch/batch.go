@@ -0,0 +1,83 @@
+// Package batch gathers the file pairs of one git difftool or external diff
+// run, which git hands over one process per file, so that the last process
+// can show them all at once.
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"go-diff/internal/git"
+	"go-diff/internal/models"
+)
+
+// Pair is the diff of one invocation along with the file contents it was
+// made from, which git deletes as soon as the invocation returns.
+type Pair struct {
+	Files []models.DiffFile
+	Old   map[string]string
+	New   map[string]string
+}
+
+// Position reports which of how many files of the run this invocation is,
+// from the variables git sets for external diff tools. ok is false outside
+// such a run.
+func Position() (counter, total int, ok bool) {
+	counter, err1 := strconv.Atoi(os.Getenv("GIT_DIFF_PATH_COUNTER"))
+	total, err2 := strconv.Atoi(os.Getenv("GIT_DIFF_PATH_TOTAL"))
+	return counter, total, err1 == nil && err2 == nil && counter > 0 && counter <= total
+}
+
+// Add saves p as file counter of the run. When it is the last one, it
+// returns every pair of the run in order and clears them; otherwise it
+// returns nil.
+func Add(p Pair, counter, total int) ([]Pair, error) {
+	gitDir, err := git.GitDir()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(gitDir, "go-diff", "batch")
+	if counter == 1 {
+		// Left over from a run that was interrupted.
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%06d.json", counter)), data, 0o644); err != nil {
+		return nil, err
+	}
+	if counter < total {
+		return nil, nil
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var pairs []Pair
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		var p Pair
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, os.RemoveAll(dir)
+}
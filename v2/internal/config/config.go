@@ -0,0 +1,286 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+
+	"go-diff/internal/git"
+	"go-diff/internal/keymap"
+	"go-diff/internal/review"
+	"go-diff/internal/theme"
+)
+
+// LocalFile is the name of the per-repository config file, looked up at the
+// top of the work tree.
+const LocalFile = ".go-diff.toml"
+
+// Config is the contents of a config file. The global and repository files
+// share the same format.
+type Config struct {
+	ContextLines     int                    `toml:"context_lines"`
+	ViewMode         string                 `toml:"view_mode"`
+	Theme            string                 `toml:"theme"`
+	IgnorePaths      []string               `toml:"ignore_paths"`
+	Whitespace       git.Whitespace         `toml:"whitespace"`
+	Algorithm        string                 `toml:"algorithm"`
+	FindRenames      int                    `toml:"find_renames"`
+	FindCopies       int                    `toml:"find_copies"`
+	MovedIgnoreSpace bool                   `toml:"moved_ignore_space"`
+	CommentStorage   string                 `toml:"comment_storage"`
+	Themes           map[string]theme.Theme `toml:"themes"`
+	Keys             keymap.Config          `toml:"keys"`
+}
+
+// Defaults is the configuration used when nothing else is set.
+func Defaults() Config {
+	return Config{
+		ContextLines:   3,
+		ViewMode:       "unified",
+		Theme:          "auto",
+		CommentStorage: review.StorageFile,
+		Keys:           keymap.Config{Preset: "default"},
+	}
+}
+
+// Loaded is a merged configuration together with where each value came
+// from, keyed by its dotted name, e.g. "whitespace.ignore_all_space".
+type Loaded struct {
+	Config
+	Sources map[string]string
+}
+
+// scalars are the keys that always have a value, in the order Write prints
+// them.
+var scalars = []string{
+	"context_lines",
+	"view_mode",
+	"theme",
+	"ignore_paths",
+	"whitespace.ignore_all_space",
+	"whitespace.ignore_space_change",
+	"whitespace.ignore_blank_lines",
+	"whitespace.ignore_cr_at_eol",
+	"algorithm",
+	"find_renames",
+	"find_copies",
+	"moved_ignore_space",
+	"comment_storage",
+	"keys.preset",
+}
+
+// GlobalPath returns the location of the user's config file.
+func GlobalPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-diff", "config.toml"), nil
+}
+
+// LocalPath returns the location of the current repository's config file.
+func LocalPath() (string, error) {
+	root, err := git.RepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, LocalFile), nil
+}
+
+// Load starts from Defaults and applies the global config file, then the
+// repository one. Missing files are skipped. Lists and whole themes from a
+// later file replace earlier ones; key bindings are merged per action.
+func Load() (*Loaded, error) {
+	l := &Loaded{Config: Defaults(), Sources: map[string]string{}}
+	for _, key := range scalars {
+		l.Sources[key] = "default"
+	}
+
+	var paths []string
+	if p, err := GlobalPath(); err == nil {
+		paths = append(paths, p)
+	}
+	if p, err := LocalPath(); err == nil {
+		paths = append(paths, p)
+	}
+	for _, p := range paths {
+		if err := l.merge(p); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *Loaded) merge(path string) error {
+	var c Config
+	md, err := toml.DecodeFile(path, &c)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("%s: unknown setting %q", path, undecoded[0].String())
+	}
+
+	set := func(key string, apply func()) {
+		if md.IsDefined(strings.Split(key, ".")...) {
+			apply()
+			l.Sources[key] = path
+		}
+	}
+	set("context_lines", func() { l.ContextLines = c.ContextLines })
+	set("view_mode", func() { l.ViewMode = c.ViewMode })
+	set("theme", func() { l.Theme = c.Theme })
+	set("ignore_paths", func() { l.IgnorePaths = c.IgnorePaths })
+	set("whitespace.ignore_all_space", func() { l.Whitespace.IgnoreAllSpace = c.Whitespace.IgnoreAllSpace })
+	set("whitespace.ignore_space_change", func() { l.Whitespace.IgnoreSpaceChange = c.Whitespace.IgnoreSpaceChange })
+	set("whitespace.ignore_blank_lines", func() { l.Whitespace.IgnoreBlankLines = c.Whitespace.IgnoreBlankLines })
+	set("whitespace.ignore_cr_at_eol", func() { l.Whitespace.IgnoreCRAtEOL = c.Whitespace.IgnoreCRAtEOL })
+	set("algorithm", func() { l.Algorithm = c.Algorithm })
+	set("find_renames", func() { l.FindRenames = c.FindRenames })
+	set("find_copies", func() { l.FindCopies = c.FindCopies })
+	set("moved_ignore_space", func() { l.MovedIgnoreSpace = c.MovedIgnoreSpace })
+	set("comment_storage", func() { l.CommentStorage = c.CommentStorage })
+	set("keys.preset", func() { l.Keys.Preset = c.Keys.Preset })
+
+	for action, keys := range c.Keys.Bindings {
+		if l.Keys.Bindings == nil {
+			l.Keys.Bindings = map[string][]string{}
+		}
+		l.Keys.Bindings[action] = keys
+		l.Sources["keys.bindings."+action] = path
+	}
+	for name, t := range c.Themes {
+		if l.Themes == nil {
+			l.Themes = map[string]theme.Theme{}
+		}
+		l.Themes[name] = t
+		l.Sources["themes."+name] = path
+	}
+	return nil
+}
+
+// SetFlag records that key was overridden by the named command-line flag.
+func (l *Loaded) SetFlag(key, flag string) {
+	l.Sources[key] = "flag --" + flag
+}
+
+// Validate checks the values that are not validated where they are used.
+func (l *Loaded) Validate() error {
+	if l.ContextLines < 0 {
+		return fmt.Errorf("%s: context_lines must not be negative", l.Sources["context_lines"])
+	}
+	if l.ViewMode != "unified" && l.ViewMode != "split" {
+		return fmt.Errorf("%s: view_mode must be \"unified\" or \"split\", not %q", l.Sources["view_mode"], l.ViewMode)
+	}
+	if l.Algorithm != "" && !slices.Contains(git.Algorithms, l.Algorithm) {
+		return fmt.Errorf("%s: algorithm must be one of %s, not %q", l.Sources["algorithm"], strings.Join(git.Algorithms, ", "), l.Algorithm)
+	}
+	if l.CommentStorage != review.StorageFile && l.CommentStorage != review.StorageNotes {
+		return fmt.Errorf("%s: comment_storage must be %q or %q, not %q", l.Sources["comment_storage"], review.StorageFile, review.StorageNotes, l.CommentStorage)
+	}
+	if l.FindRenames < 0 || l.FindRenames > 100 {
+		return fmt.Errorf("%s: find_renames must be a percentage from 0 to 100", l.Sources["find_renames"])
+	}
+	if l.FindCopies < 0 || l.FindCopies > 100 {
+		return fmt.Errorf("%s: find_copies must be a percentage from 0 to 100", l.Sources["find_copies"])
+	}
+	return nil
+}
+
+// DiffOptions returns the git options described by the config.
+func (l *Loaded) DiffOptions(cached bool) git.Options {
+	return git.Options{
+		Cached:       cached,
+		ContextLines: l.ContextLines,
+		IgnorePaths:  l.IgnorePaths,
+		Whitespace:   l.Whitespace,
+		Algorithm:    l.Algorithm,
+		FindRenames:  l.FindRenames,
+		FindCopies:   l.FindCopies,
+	}
+}
+
+// Write prints every effective setting in TOML syntax, each followed by a
+// comment naming where it came from.
+func (l *Loaded) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	line := func(key string, v any) {
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", key, value(v), l.Sources[key])
+	}
+
+	line("context_lines", l.ContextLines)
+	line("view_mode", l.ViewMode)
+	line("theme", l.Theme)
+	line("ignore_paths", l.IgnorePaths)
+	line("whitespace.ignore_all_space", l.Whitespace.IgnoreAllSpace)
+	line("whitespace.ignore_space_change", l.Whitespace.IgnoreSpaceChange)
+	line("whitespace.ignore_blank_lines", l.Whitespace.IgnoreBlankLines)
+	line("whitespace.ignore_cr_at_eol", l.Whitespace.IgnoreCRAtEOL)
+	line("algorithm", l.Algorithm)
+	line("find_renames", l.FindRenames)
+	line("find_copies", l.FindCopies)
+	line("moved_ignore_space", l.MovedIgnoreSpace)
+	line("comment_storage", l.CommentStorage)
+	line("keys.preset", l.Keys.Preset)
+	for _, action := range sortedKeys(l.Keys.Bindings) {
+		line("keys.bindings."+action, l.Keys.Bindings[action])
+	}
+	for _, name := range sortedKeys(l.Themes) {
+		key := "themes." + name
+		fmt.Fprintf(tw, "%s = %s\t# %s\n", key, themeValue(l.Themes[name]), l.Sources[key])
+	}
+	return tw.Flush()
+}
+
+func value(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// themeValue formats the fields a theme sets as an inline table.
+func themeValue(t theme.Theme) string {
+	var fields []string
+	v := reflect.ValueOf(t)
+	for i := 0; i < v.NumField(); i++ {
+		s := v.Field(i).String()
+		if s == "" {
+			continue
+		}
+		name := v.Type().Field(i).Tag.Get("toml")
+		fields = append(fields, fmt.Sprintf("%s = %q", name, s))
+	}
+	if len(fields) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,324 @@
+// Package export renders a parsed diff for use outside the terminal.
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	htmlfmt "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/muesli/termenv"
+
+	"go-diff/internal/align"
+	"go-diff/internal/models"
+	"go-diff/internal/stats"
+	"go-diff/internal/theme"
+)
+
+// HTMLOptions configure WriteHTML.
+type HTMLOptions struct {
+	Title string
+	// Split opens the page in the side by side view.
+	Split bool
+	Theme theme.Theme
+	// Contents returns the old and new versions of a file, which are
+	// tokenized whole for syntax highlighting. Nil turns highlighting off.
+	Contents func(path string) (old, new string)
+}
+
+// WriteHTML renders files as a single self-contained page with a file
+// index and both a unified and a split view, switched in place.
+func WriteHTML(w io.Writer, files []models.DiffFile, opts HTMLOptions) error {
+	style := styles.Get(opts.Theme.Syntax)
+	summary := stats.Compute(files)
+
+	var b strings.Builder
+	view := "unified"
+	if opts.Split {
+		view = "split"
+	}
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n", html.EscapeString(opts.Title))
+	writeCSS(&b, opts.Theme, style)
+	b.WriteString("</style>\n</head>\n")
+	fmt.Fprintf(&b, "<body class=\"%s\">\n<header>\n<h1>%s</h1>\n", view, html.EscapeString(opts.Title))
+	fmt.Fprintf(&b, "<p>%d files changed, <span class=\"gd-added\">+%d</span> <span class=\"gd-removed\">-%d</span></p>\n",
+		len(files), summary.Added, summary.Removed)
+	b.WriteString("<button onclick=\"document.body.className='unified'\">Unified</button>\n")
+	b.WriteString("<button onclick=\"document.body.className='split'\">Split</button>\n</header>\n")
+
+	b.WriteString("<nav>\n<ul>\n")
+	for i, f := range summary.Files {
+		fmt.Fprintf(&b, "<li><a href=\"#file-%d\"><span class=\"gd-status\">%s</span> %s</a> <span class=\"gd-added\">+%d</span> <span class=\"gd-removed\">-%d</span></li>\n",
+			i, f.Status, html.EscapeString(f.Name), f.Added, f.Removed)
+	}
+	b.WriteString("</ul>\n</nav>\n<main>\n")
+
+	for i, f := range files {
+		var hl *highlighted
+		if opts.Contents != nil && len(f.Hunks) > 0 {
+			old, new := opts.Contents(f.FileName)
+			hl = highlight(f.FileName, old, new)
+		}
+		writeFile(&b, i, f, hl)
+	}
+	b.WriteString("</main>\n</body>\n</html>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFile(b *strings.Builder, index int, f models.DiffFile, hl *highlighted) {
+	name := html.EscapeString(f.FileName)
+	if f.OldName != f.FileName {
+		name = html.EscapeString(f.OldName) + " → " + name
+	}
+	fmt.Fprintf(b, "<section id=\"file-%d\">\n<h2><span class=\"gd-status\">%s</span> %s</h2>\n", index, f.Status, name)
+	if len(f.Hunks) == 0 {
+		b.WriteString("<p class=\"gd-empty\">No textual changes.</p>\n</section>\n")
+		return
+	}
+
+	pairs := align.Pairs(f)
+	b.WriteString("<table class=\"chroma gd-unified\">\n")
+	for hi, h := range f.Hunks {
+		fmt.Fprintf(b, "<tr class=\"gd-hunk\"><td colspan=\"3\">%s</td></tr>\n", html.EscapeString(h.Header))
+		for li, line := range h.Lines {
+			fmt.Fprintf(b, "<tr class=\"%s\"><td class=\"gd-num\">%s</td><td class=\"gd-num\">%s</td><td class=\"gd-code\">%s%s</td></tr>\n",
+				lineClass(line), number(line.OldNum), number(line.NewNum),
+				html.EscapeString(line.Type), lineHTML(hl, f, pairs, hi, li))
+		}
+	}
+	b.WriteString("</table>\n<table class=\"chroma gd-split\">\n")
+	for hi, h := range f.Hunks {
+		fmt.Fprintf(b, "<tr class=\"gd-hunk\"><td colspan=\"4\">%s</td></tr>\n", html.EscapeString(h.Header))
+		for _, row := range align.Rows(h) {
+			b.WriteString("<tr>")
+			writeSide(b, hl, f, pairs, hi, row.Left, true)
+			writeSide(b, hl, f, pairs, hi, row.Right, false)
+			b.WriteString("</tr>\n")
+		}
+	}
+	b.WriteString("</table>\n</section>\n")
+}
+
+func writeSide(b *strings.Builder, hl *highlighted, f models.DiffFile, pairs []map[int]int, hunk, idx int, old bool) {
+	if idx < 0 {
+		b.WriteString("<td class=\"gd-num gd-filler\"></td><td class=\"gd-code gd-filler\"></td>")
+		return
+	}
+	line := f.Hunks[hunk].Lines[idx]
+	num := line.NewNum
+	if old {
+		num = line.OldNum
+	}
+	class := lineClass(line)
+	fmt.Fprintf(b, "<td class=\"gd-num %s\">%s</td><td class=\"gd-code %s\">%s</td>",
+		class, number(num), class, lineHTML(hl, f, pairs, hunk, idx))
+}
+
+func lineClass(line models.DiffLine) string {
+	switch line.Type {
+	case "+":
+		return "gd-add"
+	case "-":
+		return "gd-del"
+	}
+	return "gd-context"
+}
+
+func number(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// piece is a run of a line that shares one token class.
+type piece struct {
+	text     string
+	class    string
+	emphasis bool
+}
+
+// lineHTML renders the content of a line without its +/- marker, with
+// syntax classes and the part that differs from its paired line marked.
+func lineHTML(hl *highlighted, f models.DiffFile, pairs []map[int]int, hunk, idx int) string {
+	line := f.Hunks[hunk].Lines[idx]
+	text := lineText(line)
+	pieces := hl.pieces(line)
+	if pieces == nil {
+		pieces = []piece{{text: text}}
+	}
+	if partner, ok := pairs[hunk][idx]; ok {
+		start, end := align.ChangedSpan(text, lineText(f.Hunks[hunk].Lines[partner]))
+		if start < end && (start > 0 || end < len(text)) {
+			pieces = emphasize(pieces, start, end)
+		}
+	}
+
+	var b strings.Builder
+	for _, p := range pieces {
+		class := p.class
+		if p.emphasis {
+			class = strings.TrimSpace(class + " gd-em")
+		}
+		if class == "" {
+			b.WriteString(html.EscapeString(p.text))
+			continue
+		}
+		fmt.Fprintf(&b, "<span class=\"%s\">%s</span>", class, html.EscapeString(p.text))
+	}
+	return b.String()
+}
+
+// emphasize marks the bytes [start, end) of the text spanned by pieces,
+// splitting pieces at the edges.
+func emphasize(pieces []piece, start, end int) []piece {
+	var out []piece
+	pos := 0
+	for _, p := range pieces {
+		pStart, pEnd := pos, pos+len(p.text)
+		pos = pEnd
+		if pEnd <= start || pStart >= end {
+			out = append(out, p)
+			continue
+		}
+		from := max(start, pStart) - pStart
+		to := min(end, pEnd) - pStart
+		if from > 0 {
+			out = append(out, piece{text: p.text[:from], class: p.class})
+		}
+		out = append(out, piece{text: p.text[from:to], class: p.class, emphasis: true})
+		if to < len(p.text) {
+			out = append(out, piece{text: p.text[to:], class: p.class})
+		}
+	}
+	return out
+}
+
+func lineText(line models.DiffLine) string {
+	return strings.TrimPrefix(line.Content, line.Type)
+}
+
+// highlighted holds both versions of a file tokenized as a whole, split by
+// line.
+type highlighted struct {
+	old, new       [][]piece
+	oldRaw, newRaw []string
+}
+
+func highlight(name, old, new string) *highlighted {
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		return nil
+	}
+	lexer = chroma.Coalesce(lexer)
+	h := &highlighted{}
+	h.old, h.oldRaw = tokenize(lexer, old)
+	h.new, h.newRaw = tokenize(lexer, new)
+	return h
+}
+
+func tokenize(lexer chroma.Lexer, content string) ([][]piece, []string) {
+	if content == "" {
+		return nil, nil
+	}
+	it, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return nil, nil
+	}
+	lines := [][]piece{nil}
+	for _, tok := range it.Tokens() {
+		class := tokenClass(tok.Type)
+		for i, part := range strings.Split(tok.Value, "\n") {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				last := len(lines) - 1
+				lines[last] = append(lines[last], piece{text: part, class: class})
+			}
+		}
+	}
+	return lines, strings.Split(content, "\n")
+}
+
+// pieces returns the tokens of line, or nil when the file has no
+// highlighting or the line does not match the file contents.
+func (h *highlighted) pieces(line models.DiffLine) []piece {
+	if h == nil {
+		return nil
+	}
+	lines, raw, num := h.new, h.newRaw, line.NewNum
+	if line.Type == "-" {
+		lines, raw, num = h.old, h.oldRaw, line.OldNum
+	}
+	if num < 1 || num > len(lines) || num > len(raw) || raw[num-1] != lineText(line) {
+		return nil
+	}
+	return lines[num-1]
+}
+
+// tokenClass is the CSS class chroma's stylesheet uses for t, falling back
+// to the closest parent type that has one.
+func tokenClass(t chroma.TokenType) string {
+	for ; t > 0; t = t.Parent() {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+	}
+	return ""
+}
+
+func writeCSS(b *strings.Builder, t theme.Theme, style *chroma.Style) {
+	bg := style.Get(chroma.Background)
+	fg, page := "inherit", "inherit"
+	if bg.Colour.IsSet() {
+		fg = bg.Colour.String()
+	}
+	if bg.Background.IsSet() {
+		page = bg.Background.String()
+	}
+	fmt.Fprintf(b, `body { margin: 0; font-family: sans-serif; color: %s; background: %s; }
+header, nav, main { padding: 0 1.5em; }
+nav ul { list-style: none; padding: 0; font-family: monospace; }
+h2 { font-size: 1em; font-family: monospace; padding: 0.5em; border-bottom: 1px solid %s; }
+table { border-collapse: collapse; width: 100%%; font-family: monospace; table-layout: fixed; }
+td { white-space: pre-wrap; word-break: break-all; tab-size: 4; padding: 0 0.5em; vertical-align: top; }
+td.gd-num { width: 4em; text-align: right; color: %s; user-select: none; }
+.gd-split td.gd-num { width: 3em; }
+.gd-hunk td { color: %s; padding: 0.3em 0.5em; }
+.gd-added { color: %s; }
+.gd-removed { color: %s; }
+.gd-status { font-weight: bold; }
+.gd-add, .gd-add td { background: %s; }
+.gd-del, .gd-del td { background: %s; }
+.gd-add .gd-em, td.gd-add .gd-em { background: %s; }
+.gd-del .gd-em, td.gd-del .gd-em { background: %s; }
+.gd-empty { font-style: italic; }
+body.unified .gd-split, body.split .gd-unified { display: none; }
+`,
+		fg, page, cssColor(t.Border), cssColor(t.Gutter), cssColor(t.Header),
+		cssColor(t.Add), cssColor(t.Remove),
+		cssColor(t.AddBackground), cssColor(t.RemoveBackground),
+		cssColor(t.AddEmphasis), cssColor(t.RemoveEmphasis))
+	htmlfmt.New(htmlfmt.WithClasses(true)).WriteCSS(b, style)
+}
+
+// cssColor converts a theme color, which may be an ANSI color number, to
+// CSS.
+func cssColor(c string) string {
+	n, err := strconv.Atoi(c)
+	if err != nil {
+		return c
+	}
+	if n < 16 {
+		return termenv.ConvertToRGB(termenv.ANSIColor(n)).Hex()
+	}
+	return termenv.ConvertToRGB(termenv.ANSI256Color(n)).Hex()
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"go-diff/internal/models"
+)
+
+// SchemaVersion is the version of the JSON schema below. It changes only
+// when a field is removed or changes meaning; new fields may be added
+// within a version.
+const SchemaVersion = 1
+
+// Diff is the document printed by --format json.
+type Diff struct {
+	Version int    `json:"version"`
+	Files   []File `json:"files"`
+}
+
+// File is one changed file. --format ndjson prints one File per line, each
+// carrying the schema version.
+type File struct {
+	Version int `json:"version,omitempty"`
+	// Path is the file's path on the new side, relative to the repository
+	// root. OldPath differs from it only for renames and copies.
+	Path    string `json:"path"`
+	OldPath string `json:"old_path"`
+	// Status is "A" (added), "D" (deleted), "M" (modified), "R" (renamed)
+	// or "C" (copied).
+	Status string `json:"status"`
+	// Blob is the hash of the new side, empty if git did not print one.
+	Blob    string `json:"blob,omitempty"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Hunks   []Hunk `json:"hunks"`
+}
+
+// Hunk is one "@@" section of a file.
+type Hunk struct {
+	Header string `json:"header"`
+	Lines  []Line `json:"lines"`
+}
+
+// Line is one line of a hunk.
+type Line struct {
+	// Type is "context", "added", "removed", or "marker" for lines such as
+	// "\ No newline at end of file" that are not part of either side.
+	Type string `json:"type"`
+	// Content is the line without its leading +, - or space. Markers are
+	// kept whole.
+	Content string `json:"content"`
+	// OldLine and NewLine are 1-based line numbers in the old and new
+	// file, or 0 when the line does not exist on that side.
+	OldLine int `json:"old_line"`
+	NewLine int `json:"new_line"`
+}
+
+// WriteJSON prints files as a single Diff document.
+func WriteJSON(w io.Writer, files []models.DiffFile) error {
+	doc := Diff{Version: SchemaVersion, Files: []File{}}
+	for _, f := range files {
+		doc.Files = append(doc.Files, jsonFile(f))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// WriteNDJSON prints one File per line.
+func WriteNDJSON(w io.Writer, files []models.DiffFile) error {
+	enc := json.NewEncoder(w)
+	for _, f := range files {
+		file := jsonFile(f)
+		file.Version = SchemaVersion
+		if err := enc.Encode(file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func jsonFile(f models.DiffFile) File {
+	added, removed := f.Stats()
+	file := File{
+		Path:    f.FileName,
+		OldPath: f.OldName,
+		Status:  f.Status,
+		Blob:    f.Blob,
+		Added:   added,
+		Removed: removed,
+		Hunks:   []Hunk{},
+	}
+	for _, h := range f.Hunks {
+		hunk := Hunk{Header: h.Header, Lines: []Line{}}
+		for _, l := range h.Lines {
+			line := Line{Type: jsonLineType(l), Content: l.Content, OldLine: l.OldNum, NewLine: l.NewNum}
+			if line.Type != "marker" && line.Content != "" {
+				line.Content = line.Content[1:]
+			}
+			hunk.Lines = append(hunk.Lines, line)
+		}
+		file.Hunks = append(file.Hunks, hunk)
+	}
+	return file
+}
+
+func jsonLineType(l models.DiffLine) string {
+	switch {
+	case l.Type == "+":
+		return "added"
+	case l.Type == "-":
+		return "removed"
+	case l.OldNum == 0 && l.NewNum == 0:
+		return "marker"
+	}
+	return "context"
+}
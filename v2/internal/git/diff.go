@@ -2,18 +2,205 @@ package git
 
 import (
 	"bytes"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
-func GetDiff(cached bool)(string, error) {
-	args := []string{"diff", "--unified=3"}
-	if cached {
+// Options select what GetDiff compares and how.
+type Options struct {
+	Cached       bool
+	ContextLines int
+	// IgnorePaths are pathspecs, relative to the top of the work tree, left
+	// out of the diff.
+	IgnorePaths []string
+	Whitespace  Whitespace
+
+	// Algorithm is one of Algorithms, or "" for git's configured default.
+	Algorithm string
+	// FindRenames and FindCopies are similarity thresholds in percent. Zero
+	// leaves rename detection at git's default and copy detection off.
+	FindRenames int
+	FindCopies  int
+}
+
+// Algorithms are the diff algorithms git supports.
+var Algorithms = []string{"myers", "minimal", "patience", "histogram"}
+
+// Whitespace holds git's whitespace-ignoring diff options.
+type Whitespace struct {
+	IgnoreAllSpace    bool `toml:"ignore_all_space"`
+	IgnoreSpaceChange bool `toml:"ignore_space_change"`
+	IgnoreBlankLines  bool `toml:"ignore_blank_lines"`
+	IgnoreCRAtEOL     bool `toml:"ignore_cr_at_eol"`
+}
+
+func (w Whitespace) args() []string {
+	var args []string
+	if w.IgnoreAllSpace {
+		args = append(args, "--ignore-all-space")
+	}
+	if w.IgnoreSpaceChange {
+		args = append(args, "--ignore-space-change")
+	}
+	if w.IgnoreBlankLines {
+		args = append(args, "--ignore-blank-lines")
+	}
+	if w.IgnoreCRAtEOL {
+		args = append(args, "--ignore-cr-at-eol")
+	}
+	return args
+}
+
+func GetDiff(opts Options) (string, error) {
+	args := append([]string{"diff"}, opts.args()...)
+	if opts.Cached {
 		args = append(args, "--cached")
 	}
-	
+	if len(opts.IgnorePaths) > 0 {
+		args = append(args, "--")
+		for _, p := range opts.IgnorePaths {
+			args = append(args, ":(top,exclude)"+p)
+		}
+	}
+
 	cmd := exec.Command("git", args...)
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	err := cmd.Run()
 	return out.String(), err
-}
\ No newline at end of file
+}
+
+// DiffFiles compares two files or directories that need not be tracked, as
+// git diff --no-index does. Cached and IgnorePaths do not apply.
+func DiffFiles(opts Options, old, new string) (string, error) {
+	args := append([]string{"diff", "--no-index"}, opts.args()...)
+	cmd := exec.Command("git", append(args, "--", old, new)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := cmd.Run()
+	// Like diff(1), git exits 1 when the files differ.
+	if exit, ok := err.(*exec.ExitError); ok && exit.ExitCode() == 1 {
+		err = nil
+	}
+	return out.String(), err
+}
+
+// args are the options shared by every kind of diff.
+func (opts Options) args() []string {
+	// Full blob hashes in the index lines identify the content each file
+	// was reviewed at.
+	args := []string{"--full-index", fmt.Sprintf("--unified=%d", opts.ContextLines)}
+	args = append(args, opts.Whitespace.args()...)
+	if opts.Algorithm != "" {
+		args = append(args, "--diff-algorithm="+opts.Algorithm)
+	}
+	if opts.FindRenames > 0 {
+		args = append(args, fmt.Sprintf("--find-renames=%d%%", opts.FindRenames))
+	}
+	if opts.FindCopies > 0 {
+		args = append(args, fmt.Sprintf("--find-copies=%d%%", opts.FindCopies))
+	}
+	return args
+}
+
+// GetFileContents returns the full old and new versions of path as compared
+// by GetDiff. A side that does not exist (added or deleted files) is empty.
+func GetFileContents(cached bool, path string) (string, string) {
+	if cached {
+		return show("HEAD:" + path), show(":" + path)
+	}
+
+	root, err := RepoRoot()
+	if err != nil {
+		return show(":" + path), ""
+	}
+	data, _ := os.ReadFile(filepath.Join(root, path))
+	return show(":" + path), string(data)
+}
+
+func show(object string) string {
+	cmd := exec.Command("git", "show", object)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return ""
+	}
+	return out.String()
+}
+
+// RepoRoot returns the top-level directory of the current repository.
+func RepoRoot() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitDir returns the absolute path of the current repository's git
+// directory, which is not always .git under the work tree.
+func GitDir() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--absolute-git-dir").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Ignored returns the subset of paths that .gitignore rules exclude.
+func Ignored(paths []string) map[string]bool {
+	cmd := exec.Command("git", "check-ignore", "--stdin", "-z")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00") + "\x00")
+	// check-ignore exits 1 when nothing is ignored.
+	out, _ := cmd.Output()
+
+	ignored := map[string]bool{}
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			ignored[p] = true
+		}
+	}
+	return ignored
+}
+
+// RootCommit returns the first commit reachable from HEAD.
+func RootCommit() (string, error) {
+	out, err := exec.Command("git", "rev-list", "--max-parents=0", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	roots := strings.Fields(string(out))
+	if len(roots) == 0 {
+		return "", fmt.Errorf("no root commit")
+	}
+	return roots[len(roots)-1], nil
+}
+
+// ReadNote returns the note attached to object under refs/notes/<ref>, or
+// "" if there is none.
+func ReadNote(ref, object string) (string, error) {
+	cmd := exec.Command("git", "notes", "--ref="+ref, "show", object)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if strings.Contains(stderr.String(), "no note found") {
+			return "", nil
+		}
+		return "", fmt.Errorf("git notes: %s", strings.TrimSpace(stderr.String()))
+	}
+	return out.String(), nil
+}
+
+// WriteNote replaces the note attached to object under refs/notes/<ref>.
+func WriteNote(ref, object, content string) error {
+	cmd := exec.Command("git", "notes", "--ref="+ref, "add", "--force", "--file=-", object)
+	cmd.Stdin = strings.NewReader(content)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git notes: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,289 @@
+package keymap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scope is where a binding applies. Global bindings apply everywhere and
+// must not clash with bindings of any other scope.
+type Scope int
+
+const (
+	Global Scope = iota
+	List
+	Diff
+)
+
+func (s Scope) String() string {
+	switch s {
+	case List:
+		return "File list"
+	case Diff:
+		return "Diff pane"
+	default:
+		return "Global"
+	}
+}
+
+type Action string
+
+const (
+	Quit            Action = "quit"
+	Help            Action = "help"
+	ToggleFocus     Action = "toggle_focus"
+	ToggleSplit     Action = "toggle_split"
+	Summary         Action = "summary"
+	SidebarNarrower Action = "sidebar_narrower"
+	SidebarWider    Action = "sidebar_wider"
+	ToggleSidebar   Action = "toggle_sidebar"
+	Maximize        Action = "maximize"
+
+	IgnoreAllSpace    Action = "ignore_all_space"
+	IgnoreSpaceChange Action = "ignore_space_change"
+	IgnoreBlankLines  Action = "ignore_blank_lines"
+	IgnoreCRAtEOL     Action = "ignore_cr_at_eol"
+	DiffOptions       Action = "diff_options"
+	CommentList       Action = "comment_list"
+	ToggleViewed      Action = "toggle_viewed"
+	HideViewed        Action = "hide_viewed"
+
+	ListUp       Action = "list_up"
+	ListDown     Action = "list_down"
+	ListPageUp   Action = "list_page_up"
+	ListPageDown Action = "list_page_down"
+	ListTop      Action = "list_top"
+	ListBottom   Action = "list_bottom"
+	Filter       Action = "filter"
+	ToggleDir    Action = "toggle_dir"
+	ExpandAll    Action = "expand_all"
+	CollapseAll  Action = "collapse_all"
+
+	CursorDown   Action = "cursor_down"
+	CursorUp     Action = "cursor_up"
+	ScrollDown   Action = "scroll_down"
+	ScrollUp     Action = "scroll_up"
+	HalfPageDown Action = "half_page_down"
+	HalfPageUp   Action = "half_page_up"
+	PageDown     Action = "page_down"
+	PageUp       Action = "page_up"
+	Top          Action = "top"
+	Bottom       Action = "bottom"
+	Search       Action = "search"
+	NextMatch    Action = "next_match"
+	PrevMatch    Action = "prev_match"
+	ClearSearch  Action = "clear_search"
+	JumpMoved    Action = "jump_moved"
+	Select       Action = "select"
+	Comment      Action = "comment"
+)
+
+type actionInfo struct {
+	action Action
+	scope  Scope
+	help   string
+}
+
+// actions lists every action in the order the help overlay shows them.
+var actions = []actionInfo{
+	{Quit, Global, "quit"},
+	{Help, Global, "toggle this help"},
+	{ToggleFocus, Global, "switch focus between panes"},
+	{ToggleSplit, Global, "toggle unified/split view"},
+	{Summary, Global, "diff summary"},
+	{SidebarNarrower, Global, "narrow the sidebar"},
+	{SidebarWider, Global, "widen the sidebar"},
+	{ToggleSidebar, Global, "hide/show the sidebar"},
+	{Maximize, Global, "maximize the diff pane"},
+	{IgnoreAllSpace, Global, "toggle ignoring all whitespace"},
+	{IgnoreSpaceChange, Global, "toggle ignoring whitespace changes"},
+	{IgnoreBlankLines, Global, "toggle ignoring blank lines"},
+	{IgnoreCRAtEOL, Global, "toggle ignoring CR at end of line"},
+	{DiffOptions, Global, "pick diff algorithm and rename/copy detection"},
+	{CommentList, Global, "list review comments"},
+	{ToggleViewed, Global, "mark the file viewed/unviewed"},
+	{HideViewed, Global, "hide/show viewed files"},
+
+	{ListUp, List, "previous file"},
+	{ListDown, List, "next file"},
+	{ListPageUp, List, "previous page"},
+	{ListPageDown, List, "next page"},
+	{ListTop, List, "first file"},
+	{ListBottom, List, "last file"},
+	{Filter, List, "fuzzy filter files"},
+	{ToggleDir, List, "collapse/expand directory"},
+	{ExpandAll, List, "expand all directories"},
+	{CollapseAll, List, "collapse all directories"},
+
+	{CursorDown, Diff, "next line"},
+	{CursorUp, Diff, "previous line"},
+	{ScrollDown, Diff, "scroll down"},
+	{ScrollUp, Diff, "scroll up"},
+	{HalfPageDown, Diff, "half page down"},
+	{HalfPageUp, Diff, "half page up"},
+	{PageDown, Diff, "page down"},
+	{PageUp, Diff, "page up"},
+	{Top, Diff, "go to top"},
+	{Bottom, Diff, "go to bottom"},
+	{Search, Diff, "search (tab cycles added/removed scope)"},
+	{NextMatch, Diff, "next match"},
+	{PrevMatch, Diff, "previous match"},
+	{ClearSearch, Diff, "clear search"},
+	{JumpMoved, Diff, "jump between moved code and where it came from"},
+	{Select, Diff, "start/stop selecting lines"},
+	{Comment, Diff, "comment on the line or selection"},
+}
+
+func scopeOf(a Action) (Scope, bool) {
+	for _, info := range actions {
+		if info.action == a {
+			return info.scope, true
+		}
+	}
+	return Global, false
+}
+
+// Config is the [keys] table of the config file: a preset to start from and
+// per-action overrides.
+type Config struct {
+	Preset   string              `toml:"preset"`
+	Bindings map[string][]string `toml:"bindings"`
+}
+
+// Keymap maps actions to keys and, per scope, keys back to actions.
+type Keymap struct {
+	bindings map[Action][]string
+	index    map[Scope]map[string]Action
+}
+
+// New builds the keymap described by cfg. Unknown presets and actions and
+// keys bound twice within reach of each other are errors.
+func New(cfg Config) (Keymap, error) {
+	name := cfg.Preset
+	if name == "" {
+		name = "default"
+	}
+	base, ok := presets[name]
+	if !ok {
+		return Keymap{}, fmt.Errorf("unknown key preset %q (want one of %s)", name, strings.Join(PresetNames(), ", "))
+	}
+
+	bindings := map[Action][]string{}
+	for a, keys := range base {
+		bindings[a] = keys
+	}
+	for name, keys := range cfg.Bindings {
+		a := Action(name)
+		if _, ok := scopeOf(a); !ok {
+			return Keymap{}, fmt.Errorf("unknown action %q in key bindings", name)
+		}
+		normalized := make([]string, len(keys))
+		for i, k := range keys {
+			normalized[i] = normalize(k)
+		}
+		bindings[a] = normalized
+	}
+
+	k := Keymap{bindings: bindings, index: map[Scope]map[string]Action{}}
+	if err := k.check(); err != nil {
+		return Keymap{}, err
+	}
+	return k, nil
+}
+
+// Default is the keymap used when nothing is configured.
+func Default() Keymap {
+	k, _ := New(Config{})
+	return k
+}
+
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// check indexes the bindings and reports every key that maps to more than
+// one action in the same scope, counting global bindings in every scope.
+func (k Keymap) check() error {
+	var conflicts []string
+	for _, info := range actions {
+		for _, key := range k.bindings[info.action] {
+			scopes := []Scope{info.scope}
+			if info.scope == Global {
+				scopes = []Scope{Global, List, Diff}
+			}
+			for _, s := range scopes {
+				if k.index[s] == nil {
+					k.index[s] = map[string]Action{}
+				}
+				if other, ok := k.index[s][key]; ok && other != info.action {
+					conflicts = append(conflicts, fmt.Sprintf("%q is bound to both %s and %s", Display(key), other, info.action))
+					continue
+				}
+				k.index[s][key] = info.action
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting key bindings:\n  %s", strings.Join(conflicts, "\n  "))
+	}
+	return nil
+}
+
+// Lookup returns the action bound to key in the given scope, or "" if none.
+func (k Keymap) Lookup(scope Scope, key string) Action {
+	if a, ok := k.index[scope][key]; ok {
+		return a
+	}
+	return k.index[Global][key]
+}
+
+func (k Keymap) Keys(a Action) []string {
+	return k.bindings[a]
+}
+
+// HelpEntry is one row of the generated help.
+type HelpEntry struct {
+	Scope Scope
+	Keys  string
+	Help  string
+}
+
+// Help lists the active bindings in display order. Unbound actions are left
+// out.
+func (k Keymap) Help() []HelpEntry {
+	var entries []HelpEntry
+	for _, info := range actions {
+		keys := k.bindings[info.action]
+		if len(keys) == 0 {
+			continue
+		}
+		shown := make([]string, len(keys))
+		for i, key := range keys {
+			shown[i] = Display(key)
+		}
+		entries = append(entries, HelpEntry{Scope: info.scope, Keys: strings.Join(shown, "/"), Help: info.help})
+	}
+	return entries
+}
+
+// normalize turns the names people write in config files into the strings
+// bubbletea reports for key presses.
+func normalize(key string) string {
+	if key == "space" {
+		return " "
+	}
+	return key
+}
+
+func Display(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
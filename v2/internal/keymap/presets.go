@@ -0,0 +1,160 @@
+package keymap
+
+var presets = map[string]map[Action][]string{
+	"default": defaultPreset,
+	"vim":     vimPreset,
+	"emacs":   emacsPreset,
+}
+
+// defaultPreset mixes vim, less and arrow keys.
+var defaultPreset = map[Action][]string{
+	Quit:            {"q", "ctrl+c"},
+	Help:            {"?"},
+	ToggleFocus:     {"tab"},
+	ToggleSplit:     {"s"},
+	Summary:         {"S"},
+	SidebarNarrower: {"<"},
+	SidebarWider:    {">"},
+	ToggleSidebar:   {"|"},
+	Maximize:        {"z"},
+
+	IgnoreAllSpace:    {"w"},
+	IgnoreSpaceChange: {"W"},
+	IgnoreBlankLines:  {"B"},
+	IgnoreCRAtEOL:     {"M"},
+	DiffOptions:       {"A"},
+	CommentList:       {"C"},
+	ToggleViewed:      {"x"},
+	HideViewed:        {"X"},
+
+	ListUp:       {"up", "k"},
+	ListDown:     {"down", "j"},
+	ListPageUp:   {"left", "h", "pgup", "b", "u"},
+	ListPageDown: {"right", "l", "pgdown", "f", "d"},
+	ListTop:      {"home", "g"},
+	ListBottom:   {"end", "G"},
+	Filter:       {"/"},
+	ToggleDir:    {"enter", " "},
+	ExpandAll:    {"+"},
+	CollapseAll:  {"-"},
+
+	CursorDown:   {"j", "down"},
+	CursorUp:     {"k", "up"},
+	ScrollDown:   {"ctrl+e", "enter"},
+	ScrollUp:     {"ctrl+y"},
+	HalfPageDown: {"ctrl+d", "d"},
+	HalfPageUp:   {"ctrl+u", "u"},
+	PageDown:     {"ctrl+f", "f", "pgdown", " "},
+	PageUp:       {"ctrl+b", "b", "pgup"},
+	Top:          {"g", "home"},
+	Bottom:       {"G", "end"},
+	Search:       {"/"},
+	NextMatch:    {"n"},
+	PrevMatch:    {"N"},
+	ClearSearch:  {"esc"},
+	JumpMoved:    {"m"},
+	Select:       {"v"},
+	Comment:      {"c"},
+}
+
+// vimPreset sticks to the keys vim itself uses.
+var vimPreset = map[Action][]string{
+	Quit:            {"q", "ctrl+c"},
+	Help:            {"?"},
+	ToggleFocus:     {"tab", "ctrl+w"},
+	ToggleSplit:     {"s"},
+	Summary:         {"S"},
+	SidebarNarrower: {"<"},
+	SidebarWider:    {">"},
+	ToggleSidebar:   {"|"},
+	Maximize:        {"z"},
+
+	IgnoreAllSpace:    {"w"},
+	IgnoreSpaceChange: {"W"},
+	IgnoreBlankLines:  {"B"},
+	IgnoreCRAtEOL:     {"M"},
+	DiffOptions:       {"A"},
+	CommentList:       {"C"},
+	ToggleViewed:      {"x"},
+	HideViewed:        {"X"},
+
+	ListUp:       {"k", "up"},
+	ListDown:     {"j", "down"},
+	ListPageUp:   {"ctrl+b", "pgup"},
+	ListPageDown: {"ctrl+f", "pgdown"},
+	ListTop:      {"g", "home"},
+	ListBottom:   {"G", "end"},
+	Filter:       {"/"},
+	ToggleDir:    {"o", "enter"},
+	ExpandAll:    {"+"},
+	CollapseAll:  {"-"},
+
+	CursorDown:   {"j", "down"},
+	CursorUp:     {"k", "up"},
+	ScrollDown:   {"ctrl+e"},
+	ScrollUp:     {"ctrl+y"},
+	HalfPageDown: {"ctrl+d"},
+	HalfPageUp:   {"ctrl+u"},
+	PageDown:     {"ctrl+f", "pgdown"},
+	PageUp:       {"ctrl+b", "pgup"},
+	Top:          {"g", "home"},
+	Bottom:       {"G", "end"},
+	Search:       {"/"},
+	NextMatch:    {"n"},
+	PrevMatch:    {"N"},
+	ClearSearch:  {"esc"},
+	JumpMoved:    {"%"},
+	Select:       {"V", "v"},
+	Comment:      {"c"},
+}
+
+// emacsPreset uses control and meta chords.
+var emacsPreset = map[Action][]string{
+	Quit:            {"ctrl+c", "q"},
+	Help:            {"ctrl+h", "?"},
+	ToggleFocus:     {"tab"},
+	ToggleSplit:     {"alt+s"},
+	Summary:         {"alt+S"},
+	SidebarNarrower: {"alt+{"},
+	SidebarWider:    {"alt+}"},
+	ToggleSidebar:   {"alt+|"},
+	Maximize:        {"alt+z"},
+
+	IgnoreAllSpace:    {"alt+w"},
+	IgnoreSpaceChange: {"alt+W"},
+	IgnoreBlankLines:  {"alt+B"},
+	IgnoreCRAtEOL:     {"alt+M"},
+	DiffOptions:       {"alt+a"},
+	CommentList:       {"alt+C"},
+	ToggleViewed:      {"alt+x"},
+	HideViewed:        {"alt+X"},
+
+	ListUp:       {"ctrl+p", "up"},
+	ListDown:     {"ctrl+n", "down"},
+	ListPageUp:   {"alt+v", "pgup"},
+	ListPageDown: {"ctrl+v", "pgdown"},
+	ListTop:      {"alt+<", "home"},
+	ListBottom:   {"alt+>", "end"},
+	Filter:       {"ctrl+s"},
+	ToggleDir:    {"enter", " "},
+	ExpandAll:    {"alt++"},
+	CollapseAll:  {"alt+-"},
+
+	CursorDown:   {"ctrl+n", "down"},
+	CursorUp:     {"ctrl+p", "up"},
+	ScrollDown:   {"ctrl+e"},
+	ScrollUp:     {"ctrl+y"},
+	HalfPageDown: {"alt+n"},
+	HalfPageUp:   {"alt+p"},
+	PageDown:     {"ctrl+v", "pgdown"},
+	PageUp:       {"alt+v", "pgup"},
+	Top:          {"alt+<", "home"},
+	Bottom:       {"alt+>", "end"},
+	Search:       {"ctrl+s"},
+	NextMatch:    {"ctrl+f"},
+	PrevMatch:    {"ctrl+b"},
+	ClearSearch:  {"ctrl+g", "esc"},
+	JumpMoved:    {"alt+m"},
+	Select:       {"ctrl+@"},
+	Comment:      {"alt+c"},
+}
@@ -1,16 +1,36 @@
 package models
 
 type DiffFile struct {
-    FileName string
-    Hunks    []DiffHunk
+	FileName string
+	OldName  string // previous path for renames, otherwise equal to FileName
+	Status   string // "A", "D", "M", "R" or "C"
+	Blob     string // hash of the new side, empty when git prints no index line
+	Hunks    []DiffHunk
 }
 
 type DiffHunk struct {
-    Header string
-    Lines  []DiffLine
+	Header string
+	Lines  []DiffLine
 }
 
 type DiffLine struct {
-    Type    string // "+", "-", or " "
-    Content string
+	Type    string // "+", "-", or " "
+	Content string
+	OldNum  int // line number in the old file, 0 for added lines
+	NewNum  int // line number in the new file, 0 for removed lines
+}
+
+// Stats counts the added and removed lines of the file.
+func (f DiffFile) Stats() (added, removed int) {
+	for _, h := range f.Hunks {
+		for _, line := range h.Lines {
+			switch line.Type {
+			case "+":
+				added++
+			case "-":
+				removed++
+			}
+		}
+	}
+	return added, removed
 }
@@ -0,0 +1,160 @@
+package moved
+
+import (
+	"strings"
+	"unicode"
+
+	"go-diff/internal/models"
+)
+
+// A block must span MinLines lines and MinChars non-blank characters to
+// count as moved, so that stray braces and blank lines do not.
+const (
+	MinLines = 3
+	MinChars = 20
+)
+
+// Line identifies a line of the diff.
+type Line struct {
+	File, Hunk, Line int
+}
+
+// Block is a run of removed lines that reappears, line for line, as a run
+// of added lines somewhere in the diff.
+type Block struct {
+	From []Line
+	To   []Line
+}
+
+// Moves are the moved blocks of a diff.
+type Moves struct {
+	Blocks []Block
+	byLine map[Line]int
+}
+
+type run struct {
+	file, hunk int
+	lines      []int
+}
+
+// Detect finds removed blocks that were added back elsewhere, in the same
+// file or another one. With ignoreSpace, lines that differ only in
+// whitespace still match.
+func Detect(files []models.DiffFile, ignoreSpace bool) Moves {
+	key := func(l models.DiffLine) string {
+		text := l.Content[1:]
+		if ignoreSpace {
+			return strings.Join(strings.Fields(text), "")
+		}
+		return text
+	}
+
+	removed, added := runs(files, "-"), runs(files, "+")
+	// Index every added line by content, as positions in added.
+	index := map[string][][2]int{}
+	for ri, r := range added {
+		for i, li := range r.lines {
+			k := key(files[r.file].Hunks[r.hunk].Lines[li])
+			index[k] = append(index[k], [2]int{ri, i})
+		}
+	}
+
+	m := Moves{byLine: map[Line]int{}}
+	used := map[Line]bool{}
+	lineAt := func(r run, i int) models.DiffLine {
+		return files[r.file].Hunks[r.hunk].Lines[r.lines[i]]
+	}
+
+	for _, from := range removed {
+		for i := 0; i < len(from.lines); {
+			best, bestRun, bestStart := 0, -1, 0
+			for _, pos := range index[key(lineAt(from, i))] {
+				to := added[pos[0]]
+				n := 0
+				for i+n < len(from.lines) && pos[1]+n < len(to.lines) &&
+					!used[Line{to.file, to.hunk, to.lines[pos[1]+n]}] &&
+					key(lineAt(from, i+n)) == key(lineAt(to, pos[1]+n)) {
+					n++
+				}
+				if n > best {
+					best, bestRun, bestStart = n, pos[0], pos[1]
+				}
+			}
+
+			if best < MinLines || chars(files, from, i, best) < MinChars {
+				i++
+				continue
+			}
+			to := added[bestRun]
+			var b Block
+			for n := 0; n < best; n++ {
+				f := Line{from.file, from.hunk, from.lines[i+n]}
+				t := Line{to.file, to.hunk, to.lines[bestStart+n]}
+				b.From = append(b.From, f)
+				b.To = append(b.To, t)
+				used[t] = true
+				m.byLine[f] = len(m.Blocks)
+				m.byLine[t] = len(m.Blocks)
+			}
+			m.Blocks = append(m.Blocks, b)
+			i += best
+		}
+	}
+	return m
+}
+
+// runs collects the consecutive lines of the given type in every hunk.
+func runs(files []models.DiffFile, lineType string) []run {
+	var out []run
+	for fi, f := range files {
+		for hi, h := range f.Hunks {
+			var cur *run
+			for li, l := range h.Lines {
+				if l.Type != lineType {
+					cur = nil
+					continue
+				}
+				if cur == nil {
+					out = append(out, run{file: fi, hunk: hi})
+					cur = &out[len(out)-1]
+				}
+				cur.lines = append(cur.lines, li)
+			}
+		}
+	}
+	return out
+}
+
+// chars counts the non-blank characters of n lines of r starting at i.
+func chars(files []models.DiffFile, r run, i, n int) int {
+	count := 0
+	for _, li := range r.lines[i : i+n] {
+		for _, c := range files[r.file].Hunks[r.hunk].Lines[li].Content[1:] {
+			if !unicode.IsSpace(c) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Block returns the index of the block holding l, if l was moved.
+func (m Moves) Block(l Line) (int, bool) {
+	b, ok := m.byLine[l]
+	return b, ok
+}
+
+// Counterpart returns the first line at the other end of the block holding
+// l: the destination for a removed line, the source for an added one.
+func (m Moves) Counterpart(l Line) (Line, bool) {
+	b, ok := m.byLine[l]
+	if !ok {
+		return Line{}, false
+	}
+	for _, from := range m.Blocks[b].From {
+		if from == l {
+			return m.Blocks[b].To[0], true
+		}
+	}
+	return m.Blocks[b].From[0], true
+}
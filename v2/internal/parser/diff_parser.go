@@ -1,65 +1,172 @@
 package parser
 
 import (
-    "strings"
-    "go-diff/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go-diff/internal/models"
 )
 
 func ParseGitDiff(raw string) []models.DiffFile {
-    var files []models.DiffFile
-    var currentFile *models.DiffFile
-    var currentHunk *models.DiffHunk
+	var files []models.DiffFile
+	var currentFile *models.DiffFile
+	var currentHunk *models.DiffHunk
+	var oldNum, newNum int
+	// oldLeft and newLeft count the lines the current hunk still has on
+	// each side, so that text after it, such as the commit headers of
+	// git log -p, is not taken for context.
+	var oldLeft, newLeft int
+
+	lines := strings.Split(raw, "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "diff --git") {
+			if currentHunk != nil && currentFile != nil {
+				currentFile.Hunks = append(currentFile.Hunks, *currentHunk)
+			}
+			if currentFile != nil {
+				files = append(files, *currentFile)
+			}
+			name := parseFileName(line)
+			currentFile = &models.DiffFile{FileName: name, OldName: name, Status: "M"}
+			currentHunk = nil
+		} else if strings.HasPrefix(line, "@@") && currentFile != nil {
+			if currentHunk != nil {
+				currentFile.Hunks = append(currentFile.Hunks, *currentHunk)
+			}
+			currentHunk = &models.DiffHunk{Header: line}
+			oldNum, oldLeft, newNum, newLeft = parseHunkHeader(line)
+		} else if currentFile != nil && currentHunk == nil {
+			parseExtendedHeader(currentFile, line)
+		} else if currentHunk != nil && line != "" {
+			if oldLeft <= 0 && newLeft <= 0 && !strings.HasPrefix(line, `\`) {
+				currentFile.Hunks = append(currentFile.Hunks, *currentHunk)
+				currentHunk = nil
+				continue
+			}
+			dl := models.DiffLine{
+				Type:    lineType(line),
+				Content: line,
+			}
+			switch dl.Type {
+			case "+":
+				dl.NewNum = newNum
+				newNum++
+				newLeft--
+			case "-":
+				dl.OldNum = oldNum
+				oldNum++
+				oldLeft--
+			default:
+				if !strings.HasPrefix(line, `\`) {
+					dl.OldNum, dl.NewNum = oldNum, newNum
+					oldNum++
+					newNum++
+					oldLeft--
+					newLeft--
+				}
+			}
+			currentHunk.Lines = append(currentHunk.Lines, dl)
+		}
+	}
+
+	if currentHunk != nil && currentFile != nil {
+		currentFile.Hunks = append(currentFile.Hunks, *currentHunk)
+	}
 
-    lines := strings.Split(raw, "\n")
-    for _, line := range lines {
-        if strings.HasPrefix(line, "diff --git") {
-            if currentFile != nil {
-                files = append(files, *currentFile)
-            }
-            currentFile = &models.DiffFile{FileName: parseFileName(line)}
-            currentHunk = nil
-        } else if strings.HasPrefix(line, "@@") && currentFile != nil {
-            if currentHunk != nil {
-                currentFile.Hunks = append(currentFile.Hunks, *currentHunk)
-            }
-            currentHunk = &models.DiffHunk{Header: line}
-        } else if currentHunk != nil {
-            currentHunk.Lines = append(currentHunk.Lines, models.DiffLine{
-                Type:    lineType(line),
-                Content: line,
-            })
-        }
-    }
+	if currentFile != nil {
+		files = append(files, *currentFile)
+	}
+
+	return files
+}
 
-    if currentHunk != nil && currentFile != nil {
-        currentFile.Hunks = append(currentFile.Hunks, *currentHunk)
-    }
+// ansiEscape matches the color and style sequences git adds with --color.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[A-Za-z]")
 
-    if currentFile != nil {
-        files = append(files, *currentFile)
-    }
+// StripANSI removes terminal escape sequences, so that diffs git colored
+// for a pager can be parsed.
+func StripANSI(s string) string {
+	return ansiEscape.ReplaceAllString(s, "")
+}
 
-    return files
+// DropEmpty removes modified files that have no hunks. Git still prints a
+// header for files whose changes were all whitespace it was told to ignore.
+func DropEmpty(files []models.DiffFile) []models.DiffFile {
+	kept := files[:0]
+	for _, f := range files {
+		if f.Status == "M" && len(f.Hunks) == 0 {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
 }
 
 func parseFileName(line string) string {
-    parts := strings.Split(line, " ")
-    if len(parts) >= 3 {
-        return strings.TrimPrefix(parts[2], "b/")
-    }
-    return "unknown"
+	parts := strings.Split(line, " ")
+	if len(parts) >= 3 {
+		return strings.TrimPrefix(parts[len(parts)-1], "b/")
+	}
+	return "unknown"
+}
+
+// parseExtendedHeader reads the lines git prints between "diff --git" and the
+// first hunk to learn whether the file was added, deleted, renamed or copied, and
+// the hash of its new side.
+func parseExtendedHeader(file *models.DiffFile, line string) {
+	switch {
+	case strings.HasPrefix(line, "new file mode"):
+		file.Status = "A"
+	case strings.HasPrefix(line, "deleted file mode"):
+		file.Status = "D"
+	case strings.HasPrefix(line, "rename from "):
+		file.Status = "R"
+		file.OldName = strings.TrimPrefix(line, "rename from ")
+	case strings.HasPrefix(line, "rename to "):
+		file.Status = "R"
+		file.FileName = strings.TrimPrefix(line, "rename to ")
+	case strings.HasPrefix(line, "copy from "):
+		file.Status = "C"
+		file.OldName = strings.TrimPrefix(line, "copy from ")
+	case strings.HasPrefix(line, "copy to "):
+		file.Status = "C"
+		file.FileName = strings.TrimPrefix(line, "copy to ")
+	case strings.HasPrefix(line, "index "):
+		hashes, _, _ := strings.Cut(strings.TrimPrefix(line, "index "), " ")
+		if _, blob, ok := strings.Cut(hashes, ".."); ok {
+			file.Blob = blob
+		}
+	}
+}
+
+// parseHunkHeader returns the starting line and line count of each side of
+// a hunk. Git leaves out counts of 1.
+func parseHunkHeader(line string) (oldStart, oldCount, newStart, newCount int) {
+	oldCount, newCount = 1, 1
+	if _, err := fmt.Sscanf(line, "@@ -%d,%d +%d,%d @@", &oldStart, &oldCount, &newStart, &newCount); err == nil {
+		return
+	}
+	if _, err := fmt.Sscanf(line, "@@ -%d +%d,%d @@", &oldStart, &newStart, &newCount); err == nil {
+		return
+	}
+	if _, err := fmt.Sscanf(line, "@@ -%d,%d +%d @@", &oldStart, &oldCount, &newStart); err == nil {
+		return
+	}
+	fmt.Sscanf(line, "@@ -%d +%d @@", &oldStart, &newStart)
+	return
 }
 
 func lineType(line string) string {
-    if len(line) == 0 {
-        return " "
-    }
-    switch line[0] {
-    case '+':
-        return "+"
-    case '-':
-        return "-"
-    default:
-        return " "
-    }
+	if len(line) == 0 {
+		return " "
+	}
+	switch line[0] {
+	case '+':
+		return "+"
+	case '-':
+		return "-"
+	default:
+		return " "
+	}
 }
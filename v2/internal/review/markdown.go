@@ -0,0 +1,43 @@
+package review
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMarkdown renders comments as a markdown document grouped by file,
+// quoting the lines each one is attached to.
+func WriteMarkdown(w io.Writer, comments []Comment) error {
+	sorted := append([]Comment(nil), comments...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Line < b.Line
+	})
+
+	var b strings.Builder
+	b.WriteString("# Review comments\n")
+	path := ""
+	for _, c := range sorted {
+		if c.Path != path {
+			path = c.Path
+			fmt.Fprintf(&b, "\n## %s\n", path)
+		}
+		fmt.Fprintf(&b, "\n**%s** (%s) — %s\n\n", lineRange(c), c.Side, c.Created.Format("2006-01-02 15:04"))
+		b.WriteString("```\n" + strings.Join(c.Lines, "\n") + "\n```\n\n")
+		b.WriteString(c.Body + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func lineRange(c Comment) string {
+	if c.EndLine > c.Line {
+		return fmt.Sprintf("Lines %d-%d", c.Line, c.EndLine)
+	}
+	return fmt.Sprintf("Line %d", c.Line)
+}
@@ -0,0 +1,141 @@
+package review
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-diff/internal/models"
+)
+
+// Side is the version of a file a comment refers to.
+type Side string
+
+const (
+	Old Side = "old"
+	New Side = "new"
+)
+
+// Comment is a review note on a run of lines of one side of a file. It is
+// found again by the fingerprint of those lines rather than by line
+// number, so it survives rebases and edits elsewhere in the file.
+type Comment struct {
+	ID          string    `json:"id"`
+	Path        string    `json:"path"`
+	Side        Side      `json:"side"`
+	Line        int       `json:"line"`
+	EndLine     int       `json:"end_line"`
+	Fingerprint string    `json:"fingerprint"`
+	Lines       []string  `json:"lines"`
+	Body        string    `json:"body"`
+	Created     time.Time `json:"created"`
+}
+
+// Anchor is where a comment currently sits: line indexes into one hunk of
+// one file of the diff.
+type Anchor struct {
+	File, Hunk int
+	Lines      []int
+}
+
+// NewComment attaches body to the lines of hunk between the indexes from
+// and to (inclusive) that exist on side.
+func NewComment(f models.DiffFile, hunk int, side Side, from, to int, body string) (Comment, error) {
+	var idx []int
+	for _, li := range sideLines(f.Hunks[hunk], side) {
+		if li >= from && li <= to {
+			idx = append(idx, li)
+		}
+	}
+	if len(idx) == 0 {
+		return Comment{}, fmt.Errorf("no %s lines selected", side)
+	}
+
+	lines := texts(f.Hunks[hunk], idx)
+	now := time.Now()
+	return Comment{
+		ID:          fmt.Sprintf("%x", now.UnixNano()),
+		Path:        f.FileName,
+		Side:        side,
+		Line:        number(f.Hunks[hunk].Lines[idx[0]], side),
+		EndLine:     number(f.Hunks[hunk].Lines[idx[len(idx)-1]], side),
+		Fingerprint: Fingerprint(lines),
+		Lines:       lines,
+		Body:        body,
+		Created:     now,
+	}, nil
+}
+
+// Fingerprint hashes lines with surrounding whitespace trimmed, so that
+// re-indentation does not orphan a comment.
+func Fingerprint(lines []string) string {
+	h := sha256.New()
+	for _, l := range lines {
+		h.Write([]byte(strings.TrimSpace(l)))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Locate finds the lines c is attached to in the diff, preferring the run
+// closest to where they were when the comment was written. It reports
+// false for outdated comments whose lines are gone.
+func Locate(c Comment, files []models.DiffFile) (Anchor, bool) {
+	n := len(c.Lines)
+	best, found := Anchor{}, false
+	bestDist := 0
+	for fi, f := range files {
+		if f.FileName != c.Path {
+			continue
+		}
+		for hi, h := range f.Hunks {
+			idx := sideLines(h, c.Side)
+			for start := 0; start+n <= len(idx); start++ {
+				window := idx[start : start+n]
+				if Fingerprint(texts(h, window)) != c.Fingerprint {
+					continue
+				}
+				dist := abs(number(h.Lines[window[0]], c.Side) - c.Line)
+				if !found || dist < bestDist {
+					best, bestDist, found = Anchor{File: fi, Hunk: hi, Lines: window}, dist, true
+				}
+			}
+		}
+	}
+	return best, found
+}
+
+// sideLines returns the indexes of the lines of h that exist on side.
+func sideLines(h models.DiffHunk, side Side) []int {
+	var idx []int
+	for i, l := range h.Lines {
+		if number(l, side) > 0 {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+func number(l models.DiffLine, side Side) int {
+	if side == Old {
+		return l.OldNum
+	}
+	return l.NewNum
+}
+
+func texts(h models.DiffHunk, idx []int) []string {
+	lines := make([]string, len(idx))
+	for i, li := range idx {
+		lines[i] = strings.TrimPrefix(h.Lines[li].Content, h.Lines[li].Type)
+	}
+	return lines
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,108 @@
+package review
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-diff/internal/git"
+)
+
+// Storage kinds accepted by Open.
+const (
+	StorageFile  = "file"
+	StorageNotes = "notes"
+)
+
+// notesRef is the notes namespace comments are kept under.
+const notesRef = "go-diff"
+
+// dataDir is the directory inside the git directory that holds review
+// state.
+const dataDir = "go-diff"
+
+// Store loads and saves a repository's comments.
+type Store interface {
+	Load() ([]Comment, error)
+	Save([]Comment) error
+}
+
+// Open returns the store of the given kind for the current repository.
+// "file" keeps comments in a JSON file inside the git directory; "notes"
+// keeps them in a git note on the root commit, so they can be pushed.
+func Open(kind string) (Store, error) {
+	switch kind {
+	case StorageFile, "":
+		dir, err := git.GitDir()
+		if err != nil {
+			return nil, err
+		}
+		return fileStore{path: filepath.Join(dir, dataDir, "comments.json")}, nil
+	case StorageNotes:
+		root, err := git.RootCommit()
+		if err != nil {
+			return nil, err
+		}
+		return notesStore{object: root}, nil
+	}
+	return nil, fmt.Errorf("unknown comment storage %q (want %s or %s)", kind, StorageFile, StorageNotes)
+}
+
+type fileStore struct {
+	path string
+}
+
+func (s fileStore) Load() ([]Comment, error) {
+	var comments []Comment
+	return comments, readJSON(s.path, &comments)
+}
+
+func (s fileStore) Save(comments []Comment) error {
+	return writeJSON(s.path, comments)
+}
+
+// readJSON decodes the file at path into v, leaving v alone if the file
+// does not exist.
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+type notesStore struct {
+	object string
+}
+
+func (s notesStore) Load() ([]Comment, error) {
+	note, err := git.ReadNote(notesRef, s.object)
+	if err != nil || note == "" {
+		return nil, err
+	}
+	var comments []Comment
+	return comments, json.Unmarshal([]byte(note), &comments)
+}
+
+func (s notesStore) Save(comments []Comment) error {
+	data, err := json.MarshalIndent(comments, "", "  ")
+	if err != nil {
+		return err
+	}
+	return git.WriteNote(notesRef, s.object, string(data)+"\n")
+}
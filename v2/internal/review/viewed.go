@@ -0,0 +1,51 @@
+package review
+
+import (
+	"path/filepath"
+
+	"go-diff/internal/git"
+	"go-diff/internal/models"
+)
+
+// Viewed records which files have been reviewed. Each path maps to the
+// hash of the new side it was reviewed at, so a file counts as unviewed
+// again as soon as its content changes.
+type Viewed struct {
+	path  string
+	blobs map[string]string
+}
+
+// OpenViewed loads the viewed marks of the current repository.
+func OpenViewed() (*Viewed, error) {
+	dir, err := git.GitDir()
+	if err != nil {
+		return nil, err
+	}
+	v := &Viewed{path: filepath.Join(dir, dataDir, "viewed.json"), blobs: map[string]string{}}
+	if err := readJSON(v.path, &v.blobs); err != nil {
+		return nil, err
+	}
+	if v.blobs == nil {
+		v.blobs = map[string]string{}
+	}
+	return v, nil
+}
+
+// Has reports whether f was marked viewed at its current content.
+func (v *Viewed) Has(f models.DiffFile) bool {
+	if v == nil {
+		return false
+	}
+	blob, ok := v.blobs[f.FileName]
+	return ok && blob == f.Blob
+}
+
+// Toggle marks f viewed, or unmarks it, and saves the marks.
+func (v *Viewed) Toggle(f models.DiffFile) error {
+	if v.Has(f) {
+		delete(v.blobs, f.FileName)
+	} else {
+		v.blobs[f.FileName] = f.Blob
+	}
+	return writeJSON(v.path, v.blobs)
+}
@@ -0,0 +1,115 @@
+package stats
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"go-diff/internal/models"
+)
+
+// FileStat is the line counts of one changed file.
+type FileStat struct {
+	Name    string
+	Status  string
+	Added   int
+	Removed int
+}
+
+func (f FileStat) Changes() int { return f.Added + f.Removed }
+
+// Group aggregates the files sharing an extension or top-level directory.
+type Group struct {
+	Name    string
+	Files   int
+	Added   int
+	Removed int
+}
+
+func (g Group) Changes() int { return g.Added + g.Removed }
+
+type Summary struct {
+	Files    []FileStat
+	Added    int
+	Removed  int
+	ByStatus map[string]int
+
+	// Largest holds every file ordered by total changed lines.
+	Largest     []FileStat
+	ByExtension []Group
+	ByDirectory []Group
+}
+
+// Compute builds the diffstat of a parsed diff.
+func Compute(files []models.DiffFile) Summary {
+	s := Summary{ByStatus: map[string]int{}}
+	byExt := map[string]*Group{}
+	byDir := map[string]*Group{}
+
+	for _, f := range files {
+		added, removed := f.Stats()
+		fs := FileStat{Name: f.FileName, Status: f.Status, Added: added, Removed: removed}
+		s.Files = append(s.Files, fs)
+		s.Added += added
+		s.Removed += removed
+		s.ByStatus[f.Status]++
+
+		addTo(byExt, extension(f.FileName), fs)
+		addTo(byDir, topDirectory(f.FileName), fs)
+	}
+
+	s.Largest = append([]FileStat(nil), s.Files...)
+	sort.SliceStable(s.Largest, func(i, j int) bool {
+		return s.Largest[i].Changes() > s.Largest[j].Changes()
+	})
+	s.ByExtension = sortGroups(byExt)
+	s.ByDirectory = sortGroups(byDir)
+	return s
+}
+
+// MaxChanges is the largest number of changed lines in a single file.
+func (s Summary) MaxChanges() int {
+	if len(s.Largest) == 0 {
+		return 0
+	}
+	return s.Largest[0].Changes()
+}
+
+func addTo(groups map[string]*Group, name string, fs FileStat) {
+	g, ok := groups[name]
+	if !ok {
+		g = &Group{Name: name}
+		groups[name] = g
+	}
+	g.Files++
+	g.Added += fs.Added
+	g.Removed += fs.Removed
+}
+
+func sortGroups(groups map[string]*Group) []Group {
+	out := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Changes() != out[j].Changes() {
+			return out[i].Changes() > out[j].Changes()
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func extension(name string) string {
+	if ext := path.Ext(name); ext != "" {
+		return ext
+	}
+	return "(none)"
+}
+
+func topDirectory(name string) string {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i+1]
+	}
+	return "(root)"
+}
@@ -0,0 +1,241 @@
+package theme
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Theme names a color for every styled element. Colors are anything
+// lipgloss.Color accepts: ANSI numbers ("42") or hex ("#859900").
+type Theme struct {
+	// Inherit names a theme whose colors fill in the ones left empty. Only
+	// meaningful for user-defined themes.
+	Inherit string `toml:"inherit"`
+
+	Add              string `toml:"add"`
+	Remove           string `toml:"remove"`
+	AddBackground    string `toml:"add_background"`
+	RemoveBackground string `toml:"remove_background"`
+	AddEmphasis      string `toml:"add_emphasis"`
+	RemoveEmphasis   string `toml:"remove_emphasis"`
+
+	// Moved code: lines removed in one place and added back in another.
+	MovedFrom           string `toml:"moved_from"`
+	MovedTo             string `toml:"moved_to"`
+	MovedFromBackground string `toml:"moved_from_background"`
+	MovedToBackground   string `toml:"moved_to_background"`
+
+	Header  string `toml:"header"`
+	Gutter  string `toml:"gutter"`
+	Divider string `toml:"divider"`
+
+	Cursor    string `toml:"cursor"`
+	Selection string `toml:"selection"`
+	Comment   string `toml:"comment"`
+
+	Border        string `toml:"border"`
+	FocusedBorder string `toml:"focused_border"`
+	Status        string `toml:"status"`
+	Title         string `toml:"title"`
+
+	SearchMatch     string `toml:"search_match"`
+	CurrentMatch    string `toml:"current_match"`
+	MatchForeground string `toml:"match_foreground"`
+	ListText        string `toml:"list_text"`
+	ListDim         string `toml:"list_dim"`
+	ListSelected    string `toml:"list_selected"`
+	ListTitle       string `toml:"list_title"`
+	ListTitleText   string `toml:"list_title_text"`
+
+	// Syntax is the chroma style used for syntax highlighting.
+	Syntax string `toml:"syntax"`
+}
+
+var builtin = map[string]Theme{
+	"dark": {
+		Add:                 "42",
+		Remove:              "1",
+		AddBackground:       "22",
+		RemoveBackground:    "52",
+		AddEmphasis:         "28",
+		RemoveEmphasis:      "88",
+		MovedFrom:           "13",
+		MovedTo:             "14",
+		MovedFromBackground: "53",
+		MovedToBackground:   "23",
+		Header:              "6",
+		Gutter:              "8",
+		Divider:             "8",
+		Cursor:              "12",
+		Selection:           "62",
+		Comment:             "222",
+		Border:              "8",
+		FocusedBorder:       "12",
+		Status:              "8",
+		Title:               "6",
+		SearchMatch:         "3",
+		CurrentMatch:        "208",
+		MatchForeground:     "0",
+		ListText:            "#dddddd",
+		ListDim:             "#777777",
+		ListSelected:        "#EE6FF8",
+		ListTitle:           "62",
+		ListTitleText:       "230",
+		Syntax:              "monokai",
+	},
+	"light": {
+		Add:                 "#116329",
+		Remove:              "#82071e",
+		AddBackground:       "#dafbe1",
+		RemoveBackground:    "#ffebe9",
+		AddEmphasis:         "#aceebb",
+		RemoveEmphasis:      "#ffcecb",
+		MovedFrom:           "#8250df",
+		MovedTo:             "#0550ae",
+		MovedFromBackground: "#fbefff",
+		MovedToBackground:   "#ddf4ff",
+		Header:              "#0550ae",
+		Gutter:              "#8c959f",
+		Divider:             "#d0d7de",
+		Cursor:              "#0969da",
+		Selection:           "#8250df",
+		Comment:             "#9a6700",
+		Border:              "#d0d7de",
+		FocusedBorder:       "#0969da",
+		Status:              "#57606a",
+		Title:               "#0550ae",
+		SearchMatch:         "#fff8c5",
+		CurrentMatch:        "#ffb86c",
+		MatchForeground:     "#1f2328",
+		ListText:            "#1f2328",
+		ListDim:             "#8c959f",
+		ListSelected:        "#8250df",
+		ListTitle:           "#0969da",
+		ListTitleText:       "#ffffff",
+		Syntax:              "github",
+	},
+	"solarized": {
+		Add:                 "#859900",
+		Remove:              "#dc322f",
+		AddBackground:       "#0a3a2a",
+		RemoveBackground:    "#3a1a22",
+		AddEmphasis:         "#1f5a2a",
+		RemoveEmphasis:      "#6a1f22",
+		MovedFrom:           "#d33682",
+		MovedTo:             "#268bd2",
+		MovedFromBackground: "#3a1a3a",
+		MovedToBackground:   "#0a2a4a",
+		Header:              "#2aa198",
+		Gutter:              "#586e75",
+		Divider:             "#586e75",
+		Cursor:              "#268bd2",
+		Selection:           "#6c71c4",
+		Comment:             "#b58900",
+		Border:              "#586e75",
+		FocusedBorder:       "#268bd2",
+		Status:              "#586e75",
+		Title:               "#b58900",
+		SearchMatch:         "#b58900",
+		CurrentMatch:        "#cb4b16",
+		MatchForeground:     "#002b36",
+		ListText:            "#93a1a1",
+		ListDim:             "#586e75",
+		ListSelected:        "#d33682",
+		ListTitle:           "#6c71c4",
+		ListTitleText:       "#fdf6e3",
+		Syntax:              "solarized-dark",
+	},
+	"high-contrast": {
+		Add:                 "10",
+		Remove:              "9",
+		AddBackground:       "0",
+		RemoveBackground:    "0",
+		AddEmphasis:         "2",
+		RemoveEmphasis:      "1",
+		MovedFrom:           "13",
+		MovedTo:             "14",
+		MovedFromBackground: "0",
+		MovedToBackground:   "0",
+		Header:              "14",
+		Gutter:              "15",
+		Divider:             "15",
+		Cursor:              "15",
+		Selection:           "13",
+		Comment:             "11",
+		Border:              "15",
+		FocusedBorder:       "11",
+		Status:              "15",
+		Title:               "11",
+		SearchMatch:         "11",
+		CurrentMatch:        "13",
+		MatchForeground:     "0",
+		ListText:            "15",
+		ListDim:             "7",
+		ListSelected:        "11",
+		ListTitle:           "15",
+		ListTitleText:       "0",
+		Syntax:              "hrdark",
+	},
+}
+
+// Names lists the built-in themes, plus "auto".
+func Names() []string {
+	names := []string{"auto"}
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// Resolve returns the theme called name, looking at user-defined themes
+// first. "auto" (or "") picks dark or light from the terminal background.
+func Resolve(name string, custom map[string]Theme, darkBackground bool) (Theme, error) {
+	return resolve(name, custom, darkBackground, map[string]bool{})
+}
+
+func resolve(name string, custom map[string]Theme, dark bool, seen map[string]bool) (Theme, error) {
+	if name == "" || name == "auto" {
+		if dark {
+			name = "dark"
+		} else {
+			name = "light"
+		}
+	}
+	if seen[name] {
+		return Theme{}, fmt.Errorf("theme %q inherits from itself", name)
+	}
+	seen[name] = true
+
+	if t, ok := custom[name]; ok {
+		base, err := resolve(t.Inherit, custom, dark, seen)
+		if err != nil {
+			return Theme{}, err
+		}
+		return merge(base, t), nil
+	}
+	if t, ok := builtin[name]; ok {
+		return t, nil
+	}
+
+	known := Names()
+	for n := range custom {
+		known = append(known, n)
+	}
+	return Theme{}, fmt.Errorf("unknown theme %q (want one of %s)", name, strings.Join(known, ", "))
+}
+
+// merge returns base with every non-empty color of over applied on top.
+func merge(base, over Theme) Theme {
+	b := reflect.ValueOf(&base).Elem()
+	o := reflect.ValueOf(over)
+	for i := 0; i < o.NumField(); i++ {
+		if v := o.Field(i).String(); v != "" {
+			b.Field(i).SetString(v)
+		}
+	}
+	base.Inherit = ""
+	return base
+}
@@ -0,0 +1,235 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-diff/internal/keymap"
+	"go-diff/internal/review"
+)
+
+// inlineNote is a comment shown below the last line it is attached to.
+type inlineNote struct {
+	side review.Side
+	body string
+}
+
+// commentDraft is a comment being written, with the lines it will be
+// attached to.
+type commentDraft struct {
+	input    textinput.Model
+	file     int
+	hunk     int
+	side     review.Side
+	from, to int
+}
+
+// locateComments anchors every comment in the current diff.
+func (m *model) locateComments() {
+	m.anchors = map[string]review.Anchor{}
+	for _, c := range m.comments {
+		if a, ok := review.Locate(c, m.diffData); ok {
+			m.anchors[c.ID] = a
+		}
+	}
+	m.commentVersion++
+}
+
+// fileNotes returns the comments anchored in file, keyed by hunk and the
+// index of the last line each one covers.
+func (m model) fileNotes(file int) map[[2]int][]inlineNote {
+	notes := map[[2]int][]inlineNote{}
+	for _, c := range m.comments {
+		a, ok := m.anchors[c.ID]
+		if !ok || a.File != file {
+			continue
+		}
+		key := [2]int{a.Hunk, a.Lines[len(a.Lines)-1]}
+		notes[key] = append(notes[key], inlineNote{side: c.Side, body: c.Body})
+	}
+	return notes
+}
+
+// startComment opens the comment prompt for the selection, or the line
+// under the cursor.
+func (m model) startComment() (tea.Model, tea.Cmd) {
+	if m.store == nil {
+		m.message = "comments are not available outside a git repository"
+		return m, nil
+	}
+	d, err := m.commentTarget()
+	if err != nil {
+		m.message = err.Error()
+		return m, nil
+	}
+	d.input = textinput.New()
+	d.input.Prompt = "comment: "
+	m.draft = &d
+	return m, m.draft.input.Focus()
+}
+
+// commentTarget resolves the selected rows to lines of one side of one
+// hunk. The side is the one of the line under the cursor, preferring the
+// new one.
+func (m model) commentTarget() (commentDraft, error) {
+	rows := m.diffRows()
+	if m.cursor >= len(rows) {
+		return commentDraft{}, errors.New("nothing to comment on")
+	}
+	cur := rows[m.cursor]
+	if cur.left < 0 && cur.right < 0 {
+		return commentDraft{}, errors.New("no diff line under the cursor")
+	}
+
+	file := m.selectedIndex()
+	hunk := m.diffData[file].Hunks[cur.hunk]
+	side := review.New
+	if cur.right < 0 || hunk.Lines[cur.right].Type == "-" {
+		side = review.Old
+	}
+
+	from, to := m.selection()
+	if !m.selecting {
+		from, to = m.cursor, m.cursor
+	}
+	d := commentDraft{file: file, hunk: cur.hunk, side: side, from: -1, to: -1}
+	for _, row := range rows[from : to+1] {
+		idx := row.right
+		if side == review.Old {
+			idx = row.left
+		}
+		if idx < 0 {
+			continue
+		}
+		if row.hunk != cur.hunk {
+			return commentDraft{}, errors.New("a comment cannot span several hunks")
+		}
+		if d.from < 0 {
+			d.from = idx
+		}
+		d.to = idx
+	}
+	return d, nil
+}
+
+// updateDraft handles keys while the comment prompt is open.
+func (m model) updateDraft(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.draft = nil
+		return m, nil
+	case "enter":
+		d := m.draft
+		m.draft = nil
+		body := strings.TrimSpace(d.input.Value())
+		if body == "" {
+			return m, nil
+		}
+		c, err := review.NewComment(m.diffData[d.file], d.hunk, d.side, d.from, d.to, body)
+		if err != nil {
+			m.message = err.Error()
+			return m, nil
+		}
+		m.selecting = false
+		m.setComments(append(m.comments, c))
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.draft.input, cmd = m.draft.input.Update(msg)
+	return m, cmd
+}
+
+// setComments replaces the comments and saves them.
+func (m *model) setComments(comments []review.Comment) {
+	m.comments = comments
+	m.locateComments()
+	if err := m.store.Save(comments); err != nil {
+		m.message = "saving comments: " + err.Error()
+	}
+}
+
+// updateCommentList handles keys while the comment panel is open.
+func (m model) updateCommentList(msg tea.KeyMsg, action keymap.Action) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.commentRow = max(0, m.commentRow-1)
+	case "down", "j":
+		m.commentRow = min(len(m.comments)-1, m.commentRow+1)
+	case "enter":
+		if m.commentRow < len(m.comments) {
+			m.showComment(m.comments[m.commentRow])
+		}
+	case "d", "x":
+		if m.commentRow < len(m.comments) {
+			m.setComments(append(m.comments[:m.commentRow:m.commentRow], m.comments[m.commentRow+1:]...))
+			m.commentRow = max(0, min(m.commentRow, len(m.comments)-1))
+		}
+	case "esc":
+		m.showComments = false
+	default:
+		switch action {
+		case keymap.Quit:
+			return m, tea.Quit
+		case keymap.CommentList:
+			m.showComments = false
+		}
+	}
+	return m, nil
+}
+
+// showComment closes the panel and puts the cursor on the comment's first
+// line. Outdated comments have nowhere to go.
+func (m *model) showComment(c review.Comment) {
+	a, ok := m.anchors[c.ID]
+	if !ok {
+		m.message = "this comment's lines are no longer in the diff"
+		return
+	}
+	m.showComments = false
+	if a.File != m.selectedIndex() {
+		m.selectFile(a.File)
+	}
+	m.focus = focusDiff
+	for i, row := range m.diffRows() {
+		idx := row.right
+		if c.Side == review.Old {
+			idx = row.left
+		}
+		if row.hunk == a.Hunk && idx == a.Lines[0] {
+			m.scrollTo(i - m.diffHeight()/2)
+			m.cursor = i
+			return
+		}
+	}
+}
+
+func (m model) commentsView() string {
+	var b strings.Builder
+	b.WriteString(summaryTitleStyle.Render(fmt.Sprintf("Review comments (%d)", len(m.comments))) + "\n")
+	if len(m.comments) == 0 {
+		b.WriteString("\n  No comments yet.")
+	}
+
+	width := max(20, min(100, m.width-8))
+	for i, c := range m.comments {
+		where := fmt.Sprintf("%s:%d (%s)", c.Path, c.Line, c.Side)
+		if _, ok := m.anchors[c.ID]; !ok {
+			where += " outdated"
+		}
+		body, _, _ := strings.Cut(c.Body, "\n")
+		line := truncate(fmt.Sprintf("  %s  %s", where, body), width)
+		if i == m.commentRow {
+			line = headerStyle.Render(line)
+		}
+		b.WriteString("\n" + line)
+	}
+	b.WriteString("\n\n" + statusStyle.Render("↑/↓ select • enter go to • d delete • esc close"))
+
+	box := diffStyle.Padding(1, 2).Render(b.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-diff/internal/keymap"
+)
+
+// applyListKeys points the file list's own navigation at the keymap, and
+// turns off the list bindings the model handles itself.
+func applyListKeys(km *list.KeyMap, keys keymap.Keymap) {
+	km.CursorUp.SetKeys(keys.Keys(keymap.ListUp)...)
+	km.CursorDown.SetKeys(keys.Keys(keymap.ListDown)...)
+	km.PrevPage.SetKeys(keys.Keys(keymap.ListPageUp)...)
+	km.NextPage.SetKeys(keys.Keys(keymap.ListPageDown)...)
+	km.GoToStart.SetKeys(keys.Keys(keymap.ListTop)...)
+	km.GoToEnd.SetKeys(keys.Keys(keymap.ListBottom)...)
+	km.Filter.SetKeys(keys.Keys(keymap.Filter)...)
+	km.Quit.SetEnabled(false)
+	km.ShowFullHelp.SetEnabled(false)
+	km.CloseFullHelp.SetEnabled(false)
+}
+
+// helpView renders every active binding, grouped by scope.
+func (m model) helpView() string {
+	var b strings.Builder
+	b.WriteString(summaryTitleStyle.Render("Key bindings"))
+
+	entries := m.keys.Help()
+	width := 0
+	for _, e := range entries {
+		width = max(width, lipgloss.Width(e.Keys))
+	}
+
+	scope := keymap.Scope(-1)
+	for _, e := range entries {
+		if e.Scope != scope {
+			scope = e.Scope
+			b.WriteString("\n\n" + headerStyle.Render(scope.String()))
+		}
+		fmt.Fprintf(&b, "\n  %-*s  %s", width, e.Keys, e.Help)
+	}
+
+	box := diffStyle.Padding(1, 2).Render(b.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// keyHints is the status line shown when nothing else needs it.
+func (m model) keyHints() string {
+	hint := func(a keymap.Action, what string) string {
+		keys := m.keys.Keys(a)
+		if len(keys) == 0 {
+			return ""
+		}
+		return keymap.Display(keys[0]) + " " + what
+	}
+	var parts []string
+	for _, h := range []string{
+		hint(keymap.Help, "help"),
+		hint(keymap.ToggleFocus, "focus"),
+		hint(keymap.ToggleSplit, "split"),
+		hint(keymap.Search, "search"),
+		hint(keymap.Summary, "summary"),
+		hint(keymap.Quit, "quit"),
+	} {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	return strings.Join(parts, " • ")
+}
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"go-diff/internal/models"
+)
+
+// syntaxStyle is the chroma style of the active theme.
+var syntaxStyle *chroma.Style
+
+// segment is a run of text that shares one syntax style.
+type segment struct {
+	text  string
+	style lipgloss.Style
+}
+
+// highlightedFile holds both versions of a file, tokenized as a whole so that
+// multi-line strings and comments are colored correctly, then split by line.
+type highlightedFile struct {
+	old, new       [][]segment
+	oldRaw, newRaw []string
+}
+
+func newHighlightedFile(name, oldContent, newContent string) *highlightedFile {
+	lexer := lexers.Match(name)
+	if lexer == nil {
+		return nil
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	h := &highlightedFile{}
+	h.old, h.oldRaw = highlightLines(lexer, oldContent)
+	h.new, h.newRaw = highlightLines(lexer, newContent)
+	return h
+}
+
+func highlightLines(lexer chroma.Lexer, content string) ([][]segment, []string) {
+	if content == "" {
+		return nil, nil
+	}
+	it, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return nil, nil
+	}
+
+	lines := [][]segment{nil}
+	for _, tok := range it.Tokens() {
+		style := tokenStyle(tok.Type)
+		parts := strings.Split(tok.Value, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, nil)
+			}
+			if part != "" {
+				last := len(lines) - 1
+				lines[last] = append(lines[last], segment{text: part, style: style})
+			}
+		}
+	}
+	return lines, strings.Split(content, "\n")
+}
+
+func tokenStyle(t chroma.TokenType) lipgloss.Style {
+	entry := syntaxStyle.Get(t)
+	style := lipgloss.NewStyle()
+	if entry.Colour.IsSet() {
+		style = style.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+	if entry.Bold == chroma.Yes {
+		style = style.Bold(true)
+	}
+	if entry.Italic == chroma.Yes {
+		style = style.Italic(true)
+	}
+	return style
+}
+
+// segments returns the highlighted content of line, or nil when the file has
+// no highlighting or the line does not match the file contents on disk.
+func (h *highlightedFile) segments(line models.DiffLine, old bool) []segment {
+	if h == nil {
+		return nil
+	}
+	lines, raw, num := h.new, h.newRaw, line.NewNum
+	if old {
+		lines, raw, num = h.old, h.oldRaw, line.OldNum
+	}
+	if num < 1 || num > len(lines) || num > len(raw) {
+		return nil
+	}
+	if raw[num-1] != strings.TrimPrefix(line.Content, line.Type) {
+		return nil
+	}
+	return lines[num-1]
+}
+
+// renderSegments draws segs on the given background, expanding tabs. When
+// width is positive the result is truncated or padded to exactly that width.
+func renderSegments(segs []segment, bg lipgloss.TerminalColor, width int) string {
+	var b strings.Builder
+	col := 0
+	for _, seg := range segs {
+		text := expandTabsFrom(seg.text, col)
+		if width > 0 && col+runewidth.StringWidth(text) > width {
+			text = runewidth.Truncate(text, width-col, "…")
+		}
+		col += runewidth.StringWidth(text)
+		style := seg.style
+		if _, ok := style.GetBackground().(lipgloss.NoColor); ok {
+			style = style.Background(bg)
+		}
+		b.WriteString(style.Render(text))
+		if width > 0 && col >= width {
+			break
+		}
+	}
+	if width > col {
+		b.WriteString(lipgloss.NewStyle().Background(bg).Render(strings.Repeat(" ", width-col)))
+	}
+	return b.String()
+}
@@ -1,62 +1,213 @@
 package ui
 
 import (
-	// "fmt"
-	"strings"
+	"fmt"
 
-	
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 
 	"go-diff/internal/git"
+	"go-diff/internal/keymap"
 	"go-diff/internal/models"
-	"go-diff/internal/parser"
+	"go-diff/internal/moved"
+	"go-diff/internal/review"
+	"go-diff/internal/stats"
+	"go-diff/internal/theme"
+	"go-diff/internal/watch"
 )
 
-var (
-	borderStyle     = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
-	fileListStyle   = borderStyle.Copy().Width(30).BorderForeground(lipgloss.Color("8"))
-	diffStyle       = borderStyle.Copy().BorderForeground(lipgloss.Color("7"))
+var borderStyle = lipgloss.NewStyle().Border(lipgloss.NormalBorder()).Padding(0, 1)
 
-	addStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-	removeStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
-	headerStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("6"))
+// Styles below are set from the active theme by applyTheme.
+var (
+	fileListStyle lipgloss.Style
+	diffStyle     lipgloss.Style
+
+	addStyle     lipgloss.Style
+	removeStyle  lipgloss.Style
+	headerStyle  lipgloss.Style
+	gutterStyle  lipgloss.Style
+	dividerStyle lipgloss.Style
+
+	cursorStyle    lipgloss.Style
+	selectionStyle lipgloss.Style
+	commentStyle   lipgloss.Style
+
+	statusStyle        lipgloss.Style
+	summaryTitleStyle  lipgloss.Style
+	focusedBorderColor lipgloss.Color
+
+	searchMatchStyle  lipgloss.Style
+	currentMatchStyle lipgloss.Style
+
+	addBackground    lipgloss.Color
+	removeBackground lipgloss.Color
+	addEmphasis      lipgloss.Color
+	removeEmphasis   lipgloss.Color
+
+	movedFromStyle      lipgloss.Style
+	movedToStyle        lipgloss.Style
+	movedFromBackground lipgloss.Color
+	movedToBackground   lipgloss.Color
 )
 
+type focus int
+
+const (
+	focusList focus = iota
+	focusDiff
+)
 
 type model struct {
 	list     list.Model
 	diffData []models.DiffFile
 	width    int
 	height   int
+	split    bool
+
+	diffOpts   git.Options
+	static     bool
+	contents   func(path string) (old, new string)
+	highlights map[string]*highlightedFile
+
+	focus  focus
+	offset int
+	cursor int
+
+	selecting  bool
+	selectFrom int
+	rows       *rowCache
+	search     *search
+
+	tree     *treeNode
+	treeFlat bool
+
+	moves            moved.Moves
+	movedIgnoreSpace bool
+
+	summary     stats.Summary
+	showSummary bool
+
+	layout paneLayout
+
+	keys     keymap.Keymap
+	showHelp bool
+
+	showPicker bool
+	pickerRow  int
+
+	store          review.Store
+	comments       []review.Comment
+	anchors        map[string]review.Anchor
+	commentVersion int
+	draft          *commentDraft
+	showComments   bool
+	commentRow     int
+
+	viewed     *review.Viewed
+	hideViewed bool
+
+	// message is shown in the status line until the next key press.
+	message string
+
+	// generation counts refreshes of diffData.
+	generation int
+	fetches    int
+	watcher    *watch.Watcher
+	refreshErr error
 }
 
-func NewModel(cached bool) tea.Model {
-	raw, err := git.GetDiff(cached)
-	if err != nil {
-		raw = "Error: " + err.Error()
-	}
+// Options configure a new model.
+type Options struct {
+	Diff  git.Options
+	Split bool
+	Keys  keymap.Keymap
+	Theme theme.Theme
+	// MovedIgnoreSpace lets moved-code detection match lines that differ
+	// only in whitespace.
+	MovedIgnoreSpace bool
+	// Comments, if set, stores review comments.
+	Comments review.Store
+	// Viewed, if set, keeps the files marked as reviewed.
+	Viewed *review.Viewed
+	// Watcher, if set, triggers a refresh whenever the work tree changes.
+	Watcher *watch.Watcher
+	// Input, if set, is shown instead of running git diff. It cannot be
+	// re-run with other options.
+	Input []models.DiffFile
+	// Contents returns the old and new versions of a file in Input for
+	// syntax highlighting. Without it Input is not highlighted, since the
+	// files on disk need not match it.
+	Contents func(path string) (old, new string)
+}
 
-	diffFiles := parser.ParseGitDiff(raw)
-	items := make([]list.Item, len(diffFiles))
-	for i, file := range diffFiles {
-		items[i] = listItem(file.FileName)
-	}
+// staticMessage explains why diff options do nothing for Input.
+const staticMessage = "this diff was not made by go-diff and cannot be re-run"
 
-	l := list.New(items, list.NewDefaultDelegate(), 50, 20)
-	l.Title = "Changed Files"
+func NewModel(opts Options) tea.Model {
+	applyTheme(opts.Theme)
 
-	return model{
-		list:     l,
-		diffData: diffFiles,
-		width:    100,
-		height:   30,
+	diffFiles := opts.Input
+	if diffFiles == nil {
+		raw, err := git.GetDiff(opts.Diff)
+		if err != nil {
+			raw = "Error: " + err.Error()
+		}
+		diffFiles = parseDiff(raw, opts.Diff)
+	}
+	tree := buildTree(diffFiles)
+	summary := stats.Compute(diffFiles)
+
+	l := list.New(nil, list.NewDefaultDelegate(), 50, 20)
+	l.Title = listTitle
+	themeList(&l, opts.Theme)
+	// Key hints live in the status line; the list's own help wraps badly in
+	// a narrow sidebar.
+	l.SetShowHelp(false)
+	applyListKeys(&l.KeyMap, opts.Keys)
+
+	m := model{
+		list:       l,
+		diffData:   diffFiles,
+		width:      100,
+		height:     30,
+		split:      opts.Split,
+		diffOpts:   opts.Diff,
+		highlights: map[string]*highlightedFile{},
+		search:     newSearch(),
+		rows:       &rowCache{},
+		tree:       tree,
+		summary:    summary,
+		layout:     loadLayout(),
+		keys:       opts.Keys,
+		watcher:    opts.Watcher,
+		static:     opts.Input != nil,
+		contents:   opts.Contents,
+
+		moves:            moved.Detect(diffFiles, opts.MovedIgnoreSpace),
+		movedIgnoreSpace: opts.MovedIgnoreSpace,
+
+		store:  opts.Comments,
+		viewed: opts.Viewed,
 	}
+	if m.store != nil {
+		comments, err := m.store.Load()
+		if err != nil {
+			m.message = "loading comments: " + err.Error()
+		}
+		m.comments = comments
+	}
+	m.locateComments()
+	m.markViewed()
+	m.list.SetItems(m.listItems())
+	m.loadHighlight()
+	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return nil
+	return m.waitForChange()
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -64,48 +215,381 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+		m.resize()
+	case tea.MouseMsg:
+		m.updateMouse(msg)
+		return m, nil
+	case changeMsg, diffMsg, unflagMsg:
+		return m.updateRefresh(msg)
+	case tea.KeyMsg:
+		m.message = ""
+		if m.draft != nil {
+			return m.updateDraft(msg)
+		}
+		if m.search.typing {
+			return m.updateSearchInput(msg)
+		}
+		if m.list.FilterState() == list.Filtering {
+			break
+		}
+
+		scope := keymap.List
+		if m.focus == focusDiff {
+			scope = keymap.Diff
+		}
+		action := m.keys.Lookup(scope, msg.String())
+
+		if m.showPicker {
+			return m.updatePicker(msg, action)
+		}
+		if m.showComments {
+			return m.updateCommentList(msg, action)
+		}
+		if m.showHelp || m.showSummary {
+			return m.updateOverlay(msg, action)
+		}
+		if m.updateLayout(action) {
+			return m, nil
+		}
+		switch action {
+		case keymap.Quit:
+			return m, tea.Quit
+		case keymap.Help:
+			m.showHelp = true
+			return m, nil
+		case keymap.Summary:
+			m.showSummary = true
+			return m, nil
+		case keymap.ToggleSplit:
+			m.split = !m.split
+			return m, nil
+		case keymap.DiffOptions:
+			if m.static {
+				m.message = staticMessage
+				return m, nil
+			}
+			m.showPicker = true
+			return m, nil
+		case keymap.ToggleViewed:
+			return m.toggleViewed()
+		case keymap.HideViewed:
+			return m.toggleHideViewed()
+		case keymap.CommentList:
+			m.showComments = true
+			m.commentRow = max(0, min(m.commentRow, len(m.comments)-1))
+			return m, nil
+		case keymap.IgnoreAllSpace, keymap.IgnoreSpaceChange, keymap.IgnoreBlankLines, keymap.IgnoreCRAtEOL:
+			if m.static {
+				m.message = staticMessage
+				return m, nil
+			}
+			return m.toggleWhitespace(action)
+		case keymap.ToggleFocus:
+			if !m.sidebarVisible() {
+				return m, nil
+			}
+			if m.focus == focusList {
+				m.focus = focusDiff
+			} else {
+				m.focus = focusList
+			}
+			return m, nil
+		case keymap.ToggleDir, keymap.ExpandAll, keymap.CollapseAll:
+			if m.list.FilterState() == list.Unfiltered {
+				return m.updateTree(action)
+			}
+		}
+		if m.focus == focusDiff {
+			return m.updateDiff(action)
+		}
 	}
 
+	prev := m.selectedIndex()
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+	cmd = tea.Batch(cmd, m.syncTreeItems())
+	if m.selectedIndex() != prev {
+		m.offset, m.cursor = 0, 0
+		m.selecting = false
+	}
+	m.loadHighlight()
 	return m, cmd
 }
 
+// updateOverlay handles keys while the help or summary screen covers the
+// panes; anything that would open either screen closes it again.
+func (m model) updateOverlay(msg tea.KeyMsg, action keymap.Action) (tea.Model, tea.Cmd) {
+	switch {
+	case action == keymap.Quit:
+		return m, tea.Quit
+	case action == keymap.Help, action == keymap.Summary, msg.String() == "esc":
+		m.showHelp = false
+		m.showSummary = false
+	}
+	return m, nil
+}
+
+// updateTree collapses and expands directories in the file sidebar.
+func (m model) updateTree(action keymap.Action) (tea.Model, tea.Cmd) {
+	switch action {
+	case keymap.ExpandAll:
+		m.tree.setCollapsed(false)
+	case keymap.CollapseAll:
+		m.tree.setCollapsed(true)
+	default:
+		item, ok := m.list.SelectedItem().(listItem)
+		if !ok || !item.node.isDir() {
+			return m, nil
+		}
+		item.node.collapsed = !item.node.collapsed
+	}
 
-func (m model) View() string {
-	// Get selected file
 	selected := m.list.SelectedItem()
-	var diffContent string
-
-	if selected != nil {
-		for _, f := range m.diffData {
-			if f.FileName == selected.FilterValue() {
-				for _, h := range f.Hunks {
-					diffContent += headerStyle.Render(h.Header) + "\n"
-					for _, line := range h.Lines {
-						switch line.Type {
-						case "+":
-							diffContent += addStyle.Render(line.Content) + "\n"
-						case "-":
-							diffContent += removeStyle.Render(line.Content) + "\n"
-						default:
-							diffContent += line.Content + "\n"
-						}
-					}
+	cmd := m.list.SetItems(m.listItems())
+	for i, item := range m.list.Items() {
+		if item.(listItem).node == selected.(listItem).node {
+			m.list.Select(i)
+			break
+		}
+	}
+	return m, cmd
+}
+
+// syncTreeItems swaps the sidebar between the tree and a flat list of every
+// file, so that filtering also finds files inside collapsed directories.
+func (m *model) syncTreeItems() tea.Cmd {
+	filtering := m.list.FilterState() != list.Unfiltered
+	if filtering == m.treeFlat {
+		return nil
+	}
+	m.treeFlat = filtering
+	return m.list.SetItems(m.listItems())
+}
+
+// selectFile moves the sidebar cursor to the given file, clearing any filter
+// and expanding the directories above it.
+func (m *model) selectFile(file int) {
+	m.list.ResetFilter()
+	m.syncTreeItems()
+	m.tree.expandTo(file)
+	m.list.SetItems(m.listItems())
+	for i, item := range m.list.Items() {
+		if item.(listItem).node.file == file {
+			m.list.Select(i)
+			break
+		}
+	}
+	m.loadHighlight()
+}
+
+// updateDiff handles actions while the diff pane has focus.
+func (m model) updateDiff(action keymap.Action) (tea.Model, tea.Cmd) {
+	switch action {
+	case keymap.Search:
+		m.search.typing = true
+		m.search.input.Reset()
+		return m, m.search.input.Focus()
+	case keymap.NextMatch:
+		m.search.step(1)
+		m.showMatch()
+	case keymap.PrevMatch:
+		m.search.step(-1)
+		m.showMatch()
+	case keymap.ClearSearch:
+		m.selecting = false
+		m.search.input.Reset()
+		m.search.run(m.diffData)
+	case keymap.JumpMoved:
+		m.jumpMoved()
+	case keymap.Select:
+		m.selecting = !m.selecting
+		m.selectFrom = m.cursor
+	case keymap.Comment:
+		return m.startComment()
+	default:
+		m.updateScroll(action)
+	}
+	return m, nil
+}
+
+// updateSearchInput handles keys while the search prompt is open. Matches
+// are recomputed on every keystroke.
+func (m model) updateSearchInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg.String() {
+	case "enter":
+		m.search.typing = false
+		m.search.input.Blur()
+		return m, nil
+	case "esc":
+		m.search.typing = false
+		m.search.input.Blur()
+		m.search.input.Reset()
+		m.search.run(m.diffData)
+		return m, nil
+	case "tab":
+		m.search.scope = (m.search.scope + 1) % 3
+	default:
+		m.search.input, cmd = m.search.input.Update(msg)
+	}
+	m.search.run(m.diffData)
+	m.search.first(m.selectedIndex())
+	m.showMatch()
+	return m, cmd
+}
+
+// showMatch selects the file holding the current match and scrolls the diff
+// pane so that the match is visible.
+func (m *model) showMatch() {
+	if m.search.current < 0 {
+		return
+	}
+	match := m.search.matches[m.search.current]
+	if match.file != m.selectedIndex() {
+		m.selectFile(match.file)
+	}
+
+	for i, row := range m.diffRows() {
+		if row.hunk == match.hunk && (row.left == match.line || row.right == match.line) {
+			m.scrollTo(i - m.diffHeight()/2)
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// jumpMoved takes the first moved line on screen to the other end of its
+// block, which ends up at the top of the pane.
+func (m *model) jumpMoved() {
+	file := m.selectedIndex()
+	for _, row := range m.visibleRows() {
+		for _, li := range []int{row.left, row.right} {
+			if li < 0 {
+				continue
+			}
+			target, ok := m.moves.Counterpart(moved.Line{File: file, Hunk: row.hunk, Line: li})
+			if !ok {
+				continue
+			}
+			if target.File != file {
+				m.selectFile(target.File)
+			}
+			for i, r := range m.diffRows() {
+				if r.hunk == target.Hunk && (r.left == target.Line || r.right == target.Line) {
+					m.scrollTo(i)
+					m.cursor = i
+					return
 				}
-				break
 			}
 		}
 	}
+}
 
-	// Apply styles
-	leftPane := fileListStyle.Render(m.list.View())
-	rightPane := diffStyle.Render(diffContent)
+// loadHighlight tokenizes the selected file the first time it is shown.
+func (m model) loadHighlight() {
+	f := m.selectedFile()
+	if f == nil || (m.static && m.contents == nil) {
+		return
+	}
+	if _, ok := m.highlights[f.FileName]; ok {
+		return
+	}
+	var oldContent, newContent string
+	if m.contents != nil {
+		oldContent, newContent = m.contents(f.FileName)
+	} else {
+		oldContent, newContent = git.GetFileContents(m.diffOpts.Cached, f.FileName)
+	}
+	m.highlights[f.FileName] = newHighlightedFile(f.FileName, oldContent, newContent)
+}
 
-	// Join panes horizontally
-	return lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
+func (m model) View() string {
+	if m.showHelp {
+		return m.helpView()
+	}
+	if m.showSummary {
+		return m.summaryView()
+	}
+	if m.showPicker {
+		return m.pickerView()
+	}
+	if m.showComments {
+		return m.commentsView()
+	}
+
+	if m.layout.Maximized {
+		diffPane := lipgloss.NewStyle().Width(m.diffWidth()).Height(m.diffHeight())
+		return lipgloss.JoinVertical(lipgloss.Left, diffPane.Render(m.paneText()), m.statusLine())
+	}
+
+	diffPane := m.paneStyle(diffStyle, focusDiff).
+		Width(m.diffWidth() + 2).
+		Height(m.diffHeight())
+	rightPane := diffPane.Render(m.paneText())
+
+	panes := rightPane
+	if m.sidebarVisible() {
+		leftPane := m.paneStyle(fileListStyle, focusList).
+			Width(m.sidebarWidth() - 2).
+			Height(m.diffHeight()).
+			Render(m.list.View())
+		// Join panes horizontally
+		panes = lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, panes, m.statusLine())
+}
+
+func (m model) paneStyle(style lipgloss.Style, f focus) lipgloss.Style {
+	if m.focus == f {
+		return style.BorderForeground(focusedBorderColor)
+	}
+	return style
+}
+
+func (m model) statusLine() string {
+	status := m.search.status()
+	if m.selecting {
+		from, to := m.selection()
+		status = fmt.Sprintf("selecting %s", plural(to-from+1, "row"))
+	}
+	if m.refreshErr != nil {
+		status = "refresh failed: " + m.refreshErr.Error()
+	}
+	if m.message != "" {
+		status = m.message
+	}
+	if m.draft != nil {
+		status = m.draft.input.View()
+	}
+	if status == "" {
+		status = m.keyHints()
+	}
+	if ws := whitespaceStatus(m.diffOpts.Whitespace); ws != "" {
+		status += "  [" + ws + "]"
+	}
+	if opts := diffOptionsStatus(m.diffOpts); opts != "" {
+		status += "  [" + opts + "]"
+	}
+	if pos := m.scrollPosition(); pos != "" {
+		status += "  " + pos
+	}
+	return statusStyle.Render(status)
+}
+
+func (m model) selectedFile() *models.DiffFile {
+	if idx := m.selectedIndex(); idx >= 0 {
+		return &m.diffData[idx]
+	}
+	return nil
 }
 
+func (m model) selectedIndex() int {
+	item, ok := m.list.SelectedItem().(listItem)
+	if !ok {
+		return -1
+	}
+	return item.node.file
+}
 
 // func (m model) View() string {
 // 	// Determine file list width (30% of total width)
@@ -166,15 +650,15 @@ func (m model) View() string {
 // }
 
 func padRight(s string, w int) string {
-	if len(s) > w {
-		return s[:w]
+	if runewidth.StringWidth(s) > w {
+		return truncate(s, w)
 	}
-	return s + strings.Repeat(" ", w-len(s))
+	return runewidth.FillRight(s, w)
 }
 
 func truncate(s string, w int) string {
-	if len(s) > w {
-		return s[:w-1] + "…"
+	if runewidth.StringWidth(s) > w {
+		return runewidth.Truncate(s, w, "…")
 	}
 	return s
 }
@@ -185,9 +669,3 @@ func max(a, b int) int {
 	}
 	return b
 }
-
-type listItem string
-
-func (i listItem) Title() string       { return string(i) }
-func (i listItem) Description() string { return "" }
-func (i listItem) FilterValue() string { return string(i) }
@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"go-diff/internal/keymap"
+	"go-diff/internal/state"
+)
+
+const (
+	layoutFile = "layout.json"
+
+	defaultSidebarWidth = 34
+	minSidebarWidth     = 16
+	minDiffWidth        = 20
+	sidebarStep         = 4
+)
+
+// paneLayout is the user's pane arrangement, saved between runs.
+type paneLayout struct {
+	SidebarWidth  int  `json:"sidebar_width"`
+	SidebarHidden bool `json:"sidebar_hidden"`
+	Maximized     bool `json:"maximized"`
+}
+
+func loadLayout() paneLayout {
+	l := paneLayout{SidebarWidth: defaultSidebarWidth}
+	_ = state.Load(layoutFile, &l)
+	if l.SidebarWidth <= 0 {
+		l.SidebarWidth = defaultSidebarWidth
+	}
+	return l
+}
+
+// sidebarVisible reports whether the file list takes part in the layout.
+func (m model) sidebarVisible() bool {
+	return !m.layout.SidebarHidden && !m.layout.Maximized
+}
+
+// sidebarWidth is the outer width of the file list, borders included,
+// clamped so that the diff pane always keeps some room.
+func (m model) sidebarWidth() int {
+	if !m.sidebarVisible() {
+		return 0
+	}
+	return max(minSidebarWidth, min(m.layout.SidebarWidth, m.width-minDiffWidth-4))
+}
+
+// diffWidth is the content width of the diff pane.
+func (m model) diffWidth() int {
+	if m.layout.Maximized {
+		return max(1, m.width)
+	}
+	// The diff pane's border and padding take two columns on each side.
+	return max(1, m.width-m.sidebarWidth()-4)
+}
+
+// diffHeight is the number of diff rows that fit on screen.
+func (m model) diffHeight() int {
+	if m.layout.Maximized {
+		return max(1, m.height-1)
+	}
+	// Border top and bottom, plus the status line.
+	return max(1, m.height-3)
+}
+
+// resize applies the current terminal size to the sub-components.
+func (m *model) resize() {
+	// The list sits inside a border and one column of padding per side.
+	m.list.SetSize(max(1, m.sidebarWidth()-4), max(1, m.height-3))
+	m.scroll(0)
+}
+
+// updateLayout handles the actions that rearrange panes. It reports whether
+// action was one of them.
+func (m *model) updateLayout(action keymap.Action) bool {
+	switch action {
+	case keymap.SidebarNarrower:
+		m.layout.SidebarWidth = max(minSidebarWidth, m.sidebarWidth()-sidebarStep)
+	case keymap.SidebarWider:
+		m.layout.SidebarWidth = min(m.width-minDiffWidth-4, m.sidebarWidth()+sidebarStep)
+	case keymap.ToggleSidebar:
+		m.layout.SidebarHidden = !m.layout.SidebarHidden
+	case keymap.Maximize:
+		m.layout.Maximized = !m.layout.Maximized
+	default:
+		return false
+	}
+
+	if !m.sidebarVisible() {
+		m.focus = focusDiff
+	}
+	m.resize()
+	_ = state.Save(layoutFile, m.layout)
+	return true
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-diff/internal/git"
+	"go-diff/internal/keymap"
+)
+
+// thresholds are the similarity percentages the picker steps through. Zero
+// means git's default for renames and no detection for copies.
+var thresholds = []int{0, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+
+// Rows of the diff options picker.
+const (
+	pickAlgorithm = iota
+	pickRenames
+	pickCopies
+	pickRows
+)
+
+// updatePicker handles keys while the diff options picker is open. Every
+// change re-runs the diff right away.
+func (m model) updatePicker(msg tea.KeyMsg, action keymap.Action) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.pickerRow = (m.pickerRow + pickRows - 1) % pickRows
+	case "down", "j":
+		m.pickerRow = (m.pickerRow + 1) % pickRows
+	case "left", "h":
+		return m.stepOption(-1)
+	case "right", "l", " ":
+		return m.stepOption(1)
+	case "enter", "esc":
+		m.showPicker = false
+	default:
+		switch action {
+		case keymap.Quit:
+			return m, tea.Quit
+		case keymap.DiffOptions:
+			m.showPicker = false
+		}
+	}
+	return m, nil
+}
+
+func (m model) stepOption(dir int) (tea.Model, tea.Cmd) {
+	o := &m.diffOpts
+	switch m.pickerRow {
+	case pickAlgorithm:
+		choices := append([]string{""}, git.Algorithms...)
+		o.Algorithm = choices[cycle(slices.Index(choices, o.Algorithm), dir, len(choices))]
+	case pickRenames:
+		o.FindRenames = thresholds[cycle(slices.Index(thresholds, o.FindRenames), dir, len(thresholds))]
+	case pickCopies:
+		o.FindCopies = thresholds[cycle(slices.Index(thresholds, o.FindCopies), dir, len(thresholds))]
+	}
+	return m, m.fetchDiff(false)
+}
+
+// cycle steps i by dir through n choices, wrapping around. A value that is
+// not one of the choices (i < 0) starts over from the first.
+func cycle(i, dir, n int) int {
+	if i < 0 {
+		return 0
+	}
+	return (i + dir + n) % n
+}
+
+func (m model) pickerView() string {
+	var b strings.Builder
+	b.WriteString(summaryTitleStyle.Render("Diff options") + "\n")
+
+	o := m.diffOpts
+	rows := []struct{ label, value string }{
+		{"Algorithm", algorithmName(o.Algorithm)},
+		{"Find renames", threshold(o.FindRenames, "default")},
+		{"Find copies", threshold(o.FindCopies, "off")},
+	}
+	for i, r := range rows {
+		line := fmt.Sprintf("  %-12s  ‹ %s ›", r.label, r.value)
+		if i == m.pickerRow {
+			line = headerStyle.Render(line)
+		}
+		b.WriteString("\n" + line)
+	}
+	b.WriteString("\n\n" + statusStyle.Render("↑/↓ select • ←/→ change • enter close"))
+
+	box := diffStyle.Padding(1, 2).Render(b.String())
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// diffOptionsStatus describes the algorithm and detection settings for the
+// status line when they differ from git's defaults.
+func diffOptionsStatus(o git.Options) string {
+	var parts []string
+	if o.Algorithm != "" {
+		parts = append(parts, o.Algorithm)
+	}
+	if o.FindRenames > 0 {
+		parts = append(parts, fmt.Sprintf("renames %d%%", o.FindRenames))
+	}
+	if o.FindCopies > 0 {
+		parts = append(parts, fmt.Sprintf("copies %d%%", o.FindCopies))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func threshold(percent int, zero string) string {
+	if percent == 0 {
+		return zero
+	}
+	return fmt.Sprintf("%d%%", percent)
+}
+
+func algorithmName(a string) string {
+	if a == "" {
+		return "default"
+	}
+	return a
+}
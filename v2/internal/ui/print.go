@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"io"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+
+	"go-diff/internal/models"
+	"go-diff/internal/moved"
+	"go-diff/internal/parser"
+	"go-diff/internal/theme"
+)
+
+// PrintOptions configure Print.
+type PrintOptions struct {
+	Split       bool
+	Width       int
+	LineNumbers bool
+	Theme       theme.Theme
+	// MovedIgnoreSpace lets moved-code detection match lines that differ
+	// only in whitespace.
+	MovedIgnoreSpace bool
+	// Contents, if set, returns the old and new versions of a file for
+	// syntax highlighting.
+	Contents func(path string) (old, new string)
+}
+
+// Print renders every file the way the diff pane shows it, one after the
+// other, for output that is not interactive.
+func Print(w io.Writer, files []models.DiffFile, opts PrintOptions) error {
+	applyTheme(opts.Theme)
+	moves := moved.Detect(files, opts.MovedIgnoreSpace)
+
+	var b strings.Builder
+	for i, f := range files {
+		var hl *highlightedFile
+		if opts.Contents != nil {
+			old, new := opts.Contents(f.FileName)
+			hl = newHighlightedFile(f.FileName, old, new)
+		}
+		r := diffRenderer{
+			file:    f,
+			fileIdx: i,
+			hl:      hl,
+			moves:   &moves,
+			width:   opts.Width,
+			numbers: opts.LineNumbers,
+		}
+
+		name := f.FileName
+		if f.OldName != f.FileName {
+			name = f.OldName + " → " + f.FileName
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(summaryTitleStyle.Render(truncate(f.Status+" "+name, opts.Width)) + "\n")
+		b.WriteString(dividerStyle.Render(strings.Repeat("─", opts.Width)) + "\n")
+
+		rows := r.unified()
+		if opts.Split && opts.Width >= minSplitWidth {
+			rows = r.split()
+		}
+		for _, row := range rows {
+			b.WriteString(strings.TrimRight(row.text, " ") + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Recolor restyles a diff line by line, writing exactly one line for every
+// line of raw as git's interactive.diffFilter requires. Lines outside hunks
+// keep their text.
+func Recolor(w io.Writer, raw string, opts PrintOptions) error {
+	applyTheme(opts.Theme)
+	files := parser.ParseGitDiff(raw)
+	moves := moved.Detect(files, opts.MovedIgnoreSpace)
+
+	var b strings.Builder
+	file := -1
+	var rows []diffRow
+	next, left := 0, 0 // next row to use, and hunk lines still to come
+	for _, line := range strings.Split(strings.TrimSuffix(raw, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git") && file+1 < len(files):
+			file++
+			f := files[file]
+			r := diffRenderer{file: f, fileIdx: file, moves: &moves, width: recolorWidth(f)}
+			rows, next, left = r.unified(), 0, 0
+			line = summaryTitleStyle.Render(line)
+		case strings.HasPrefix(line, "@@") && next < len(rows):
+			hunk := rows[next].hunk
+			left = len(files[file].Hunks[hunk].Lines)
+			line = strings.TrimRight(rows[next].text, " ")
+			next++
+		case left > 0 && line != "" && next < len(rows):
+			line = strings.TrimRight(rows[next].text, " ")
+			next++
+			left--
+		default:
+			line = gutterStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// recolorWidth is wide enough for every line of f, so that nothing is
+// truncated.
+func recolorWidth(f models.DiffFile) int {
+	width := 1
+	for _, h := range f.Hunks {
+		width = max(width, runewidth.StringWidth(h.Header))
+		for _, line := range h.Lines {
+			width = max(width, runewidth.StringWidth(expandTabs(line.Content)))
+		}
+	}
+	return width + 1
+}
@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-diff/internal/git"
+	"go-diff/internal/keymap"
+	"go-diff/internal/models"
+	"go-diff/internal/moved"
+	"go-diff/internal/parser"
+	"go-diff/internal/stats"
+)
+
+// changedFlagTime is how long files that changed in a refresh stay flagged
+// in the sidebar.
+const changedFlagTime = 3 * time.Second
+
+// diffMsg carries a freshly fetched and parsed diff. seq tells apart the
+// result of the latest fetch from older ones still in flight.
+type diffMsg struct {
+	seq     int
+	watched bool // fetched because the watcher saw a change
+	files   []models.DiffFile
+	err     error
+}
+
+// changeMsg reports that the watcher saw the work tree or index change.
+type changeMsg struct{ err error }
+
+// unflagMsg clears the changed flags set by refresh number gen.
+type unflagMsg struct{ gen int }
+
+// fetchDiff re-runs git diff with the current options in the background.
+func (m *model) fetchDiff(watched bool) tea.Cmd {
+	m.fetches++
+	seq, opts := m.fetches, m.diffOpts
+	return func() tea.Msg {
+		raw, err := git.GetDiff(opts)
+		if err != nil {
+			return diffMsg{seq: seq, watched: watched, err: err}
+		}
+		return diffMsg{seq: seq, watched: watched, files: parseDiff(raw, opts)}
+	}
+}
+
+// parseDiff parses git's output, dropping the files whose changes were all
+// ignored by the whitespace options.
+func parseDiff(raw string, opts git.Options) []models.DiffFile {
+	files := parser.ParseGitDiff(raw)
+	if opts.Whitespace == (git.Whitespace{}) {
+		return files
+	}
+	return parser.DropEmpty(files)
+}
+
+// waitForChange blocks on the watcher, if there is one.
+func (m model) waitForChange() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		return changeMsg{err: m.watcher.Wait()}
+	}
+}
+
+// updateRefresh handles the messages of the refresh cycle: a change starts a
+// fetch, and a fetched diff is applied before waiting for the next change.
+func (m model) updateRefresh(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case changeMsg:
+		if msg.err != nil {
+			m.refreshErr = msg.err
+			return m, nil
+		}
+		return m, m.fetchDiff(true)
+	case diffMsg:
+		var cmd tea.Cmd
+		if msg.seq == m.fetches {
+			m.refreshErr = msg.err
+			if msg.err == nil {
+				cmd = m.applyDiff(msg.files, msg.watched)
+			}
+		}
+		if msg.watched {
+			cmd = tea.Batch(cmd, m.waitForChange())
+		}
+		return m, cmd
+	case unflagMsg:
+		if msg.gen == m.generation {
+			m.tree.clearChanged()
+		}
+	}
+	return m, nil
+}
+
+// applyDiff replaces the diff in place, keeping the selected file, scroll
+// position, collapsed directories and search. With flag set, files whose
+// changes differ from before are marked for a while.
+func (m *model) applyDiff(files []models.DiffFile, flag bool) tea.Cmd {
+	selected := ""
+	if f := m.selectedFile(); f != nil {
+		selected = f.FileName
+	}
+	previous := map[string]models.DiffFile{}
+	for _, f := range m.diffData {
+		previous[f.FileName] = f
+	}
+
+	highlights := map[string]*highlightedFile{}
+	changed := map[string]bool{}
+	for _, f := range files {
+		old, ok := previous[f.FileName]
+		if ok && reflect.DeepEqual(old, f) {
+			if hl, ok := m.highlights[f.FileName]; ok {
+				highlights[f.FileName] = hl
+			}
+			continue
+		}
+		changed[f.FileName] = true
+	}
+	if len(changed) == 0 && len(files) == len(m.diffData) {
+		return nil
+	}
+
+	collapsed := m.tree.collapsedPaths()
+	m.diffData = files
+	m.highlights = highlights
+	m.summary = stats.Compute(files)
+	m.moves = moved.Detect(files, m.movedIgnoreSpace)
+	m.locateComments()
+	m.tree = buildTree(files)
+	if !flag {
+		changed = nil
+	}
+	m.tree.restore(collapsed, changed)
+	m.markViewed()
+	m.generation++
+
+	cmd := m.list.SetItems(m.listItems())
+	found := false
+	for i, item := range m.list.Items() {
+		if item.(listItem).node.path == selected && !item.(listItem).node.isDir() {
+			m.list.Select(i)
+			found = true
+			break
+		}
+	}
+	if !found {
+		m.offset, m.cursor = 0, 0
+	}
+	if m.search.input.Value() != "" {
+		m.search.run(m.diffData)
+	}
+	m.scrollTo(m.offset)
+	m.loadHighlight()
+
+	if !flag {
+		return cmd
+	}
+	gen := m.generation
+	return tea.Batch(cmd, tea.Tick(changedFlagTime, func(time.Time) tea.Msg {
+		return unflagMsg{gen: gen}
+	}))
+}
+
+// toggleWhitespace flips one of the whitespace options and re-fetches the
+// diff.
+func (m model) toggleWhitespace(action keymap.Action) (tea.Model, tea.Cmd) {
+	w := &m.diffOpts.Whitespace
+	switch action {
+	case keymap.IgnoreAllSpace:
+		w.IgnoreAllSpace = !w.IgnoreAllSpace
+	case keymap.IgnoreSpaceChange:
+		w.IgnoreSpaceChange = !w.IgnoreSpaceChange
+	case keymap.IgnoreBlankLines:
+		w.IgnoreBlankLines = !w.IgnoreBlankLines
+	case keymap.IgnoreCRAtEOL:
+		w.IgnoreCRAtEOL = !w.IgnoreCRAtEOL
+	}
+	return m, m.fetchDiff(false)
+}
+
+// whitespaceStatus lists the active whitespace options for the status line.
+func whitespaceStatus(w git.Whitespace) string {
+	var modes []string
+	if w.IgnoreAllSpace {
+		modes = append(modes, "all space")
+	}
+	if w.IgnoreSpaceChange {
+		modes = append(modes, "space change")
+	}
+	if w.IgnoreBlankLines {
+		modes = append(modes, "blank lines")
+	}
+	if w.IgnoreCRAtEOL {
+		modes = append(modes, "CR at EOL")
+	}
+	if len(modes) == 0 {
+		return ""
+	}
+	return "ignoring " + strings.Join(modes, ", ")
+}
@@ -0,0 +1,275 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"go-diff/internal/align"
+	"go-diff/internal/models"
+	"go-diff/internal/moved"
+	"go-diff/internal/review"
+)
+
+// diffRow is one rendered row of the diff pane together with the diff lines
+// it shows. Header and filler sides use -1.
+type diffRow struct {
+	text  string
+	hunk  int
+	left  int
+	right int
+}
+
+// diffRenderer turns one file of the diff into rows for the diff pane.
+type diffRenderer struct {
+	file    models.DiffFile
+	fileIdx int
+	hl      *highlightedFile
+	search  *search
+	moves   *moved.Moves
+	notes   map[[2]int][]inlineNote
+	width   int
+	// numbers adds old and new line numbers to the unified view; the split
+	// view always has them.
+	numbers bool
+
+	// pairs maps, per hunk, each changed line to the line that replaced it
+	// (or that it replaced), for intra-line highlights.
+	pairs []map[int]int
+}
+
+func (r diffRenderer) unified() []diffRow {
+	r.pairs = align.Pairs(r.file)
+	numWidth, textWidth := 0, r.width-1
+	if r.numbers {
+		numWidth = r.numberWidth()
+		if textWidth -= 2 * (numWidth + 1); textWidth < 1 {
+			r.numbers, textWidth = false, r.width-1
+		}
+	}
+
+	var rows []diffRow
+	for hi, h := range r.file.Hunks {
+		rows = append(rows, diffRow{text: headerStyle.Render(truncate(h.Header, r.width)), hunk: hi, left: -1, right: -1})
+		for li, line := range h.Lines {
+			segs, bg := r.content(hi, li, line.Type == "-")
+			text := markerStyle(r.kind(hi, li)).Render(line.Type) + renderSegments(segs, bg, textWidth)
+			if r.numbers {
+				text = gutterStyle.Render(lineNumber(line.OldNum, numWidth)+" "+lineNumber(line.NewNum, numWidth)+" ") + text
+			}
+			rows = append(rows, diffRow{text: text, hunk: hi, left: li, right: li})
+			rows = append(rows, r.noteRows(hi, li, "")...)
+		}
+	}
+	return rows
+}
+
+// split renders the file as two columns, Original on the left and Modified
+// on the right, fitting the renderer's width.
+func (r diffRenderer) split() []diffRow {
+	numWidth := r.numberWidth()
+
+	// Each side gets "<num> " plus content; the middle column is the divider.
+	sideWidth := (r.width - 1) / 2
+	textWidth := sideWidth - numWidth - 1
+	if textWidth < 1 {
+		return r.unified()
+	}
+
+	r.pairs = align.Pairs(r.file)
+	var rows []diffRow
+	for hi, h := range r.file.Hunks {
+		rows = append(rows, diffRow{text: headerStyle.Render(truncate(h.Header, r.width)), hunk: hi, left: -1, right: -1})
+		for _, row := range align.Rows(h) {
+			text := r.side(hi, row.Left, true, numWidth, textWidth) + dividerStyle.Render("│") +
+				r.side(hi, row.Right, false, numWidth, textWidth)
+			rows = append(rows, diffRow{text: text, hunk: hi, left: row.Left, right: row.Right})
+			rows = append(rows, r.noteRows(hi, row.Left, review.Old)...)
+			rows = append(rows, r.noteRows(hi, row.Right, review.New)...)
+		}
+	}
+	return rows
+}
+
+// numberWidth is the width of the file's largest line number.
+func (r diffRenderer) numberWidth() int {
+	numWidth := 1
+	for _, h := range r.file.Hunks {
+		for _, line := range h.Lines {
+			numWidth = max(numWidth, len(fmt.Sprint(max(line.OldNum, line.NewNum))))
+		}
+	}
+	return numWidth
+}
+
+// lineNumber right-aligns n in width columns, leaving 0 blank.
+func lineNumber(n, width int) string {
+	if n == 0 {
+		return strings.Repeat(" ", width)
+	}
+	return fmt.Sprintf("%*d", width, n)
+}
+
+// noteRows renders the comments that end at the given line, limited to one
+// side unless side is empty.
+func (r diffRenderer) noteRows(hunk, idx int, side review.Side) []diffRow {
+	var rows []diffRow
+	for _, n := range r.notes[[2]int{hunk, idx}] {
+		if side != "" && n.side != side {
+			continue
+		}
+		for _, line := range strings.Split(n.body, "\n") {
+			text := commentStyle.Render(truncate("  ✎ "+line, r.width))
+			rows = append(rows, diffRow{text: text, hunk: hunk, left: -1, right: -1})
+		}
+	}
+	return rows
+}
+
+func (r diffRenderer) side(hunk, idx int, old bool, numWidth, textWidth int) string {
+	if idx < 0 {
+		return strings.Repeat(" ", numWidth+1+textWidth)
+	}
+
+	line := r.file.Hunks[hunk].Lines[idx]
+	num := line.NewNum
+	if old {
+		num = line.OldNum
+	}
+	gutter := gutterStyle.Render(fmt.Sprintf("%*d ", numWidth, num))
+	if line.Type != " " {
+		gutter = markerStyle(r.kind(hunk, idx)).Render(fmt.Sprintf("%*d ", numWidth, num))
+	}
+	segs, bg := r.content(hunk, idx, old)
+	return gutter + renderSegments(segs, bg, textWidth)
+}
+
+// content returns the styled text of a line without its +/- marker, with
+// syntax highlighting when available and search matches overlaid.
+func (r diffRenderer) content(hunk, idx int, old bool) ([]segment, lipgloss.TerminalColor) {
+	line := r.file.Hunks[hunk].Lines[idx]
+	kind := r.kind(hunk, idx)
+
+	segs := r.hl.segments(line, old)
+	var bg lipgloss.TerminalColor = lipgloss.NoColor{}
+	if segs != nil {
+		bg = lineBackground(kind)
+	} else {
+		segs = []segment{{text: lineText(line), style: markerStyle(kind)}}
+	}
+
+	// Moved lines are not edits of their neighbours, so they get no
+	// intra-line highlights.
+	if partner, ok := r.pairs[hunk][idx]; ok && kind == line.Type {
+		text := lineText(line)
+		start, end := align.ChangedSpan(text, lineText(r.file.Hunks[hunk].Lines[partner]))
+		if start < end && (start > 0 || end < len(text)) {
+			bg := emphasisColor(line.Type)
+			segs = restyle(segs, start, end, func(s lipgloss.Style) lipgloss.Style { return s.Background(bg) })
+		}
+	}
+
+	if r.search != nil {
+		for _, mi := range r.search.lineMatches(r.fileIdx, hunk, idx) {
+			style := searchMatchStyle
+			if mi == r.search.current {
+				style = currentMatchStyle
+			}
+			match := r.search.matches[mi]
+			segs = restyle(segs, match.start, match.end, func(lipgloss.Style) lipgloss.Style { return style })
+		}
+	}
+	return segs, bg
+}
+
+// Kinds of moved lines, alongside the "+", "-" and " " line types.
+const (
+	movedFrom = "<"
+	movedTo   = ">"
+)
+
+// kind is the line's type, or movedFrom/movedTo if it is part of a moved
+// block.
+func (r diffRenderer) kind(hunk, idx int) string {
+	line := r.file.Hunks[hunk].Lines[idx]
+	if r.moves == nil {
+		return line.Type
+	}
+	if _, ok := r.moves.Block(moved.Line{File: r.fileIdx, Hunk: hunk, Line: idx}); !ok {
+		return line.Type
+	}
+	if line.Type == "-" {
+		return movedFrom
+	}
+	return movedTo
+}
+
+func emphasisColor(lineType string) lipgloss.Color {
+	if lineType == "+" {
+		return addEmphasis
+	}
+	return removeEmphasis
+}
+
+// lineText is the content of a diff line without its +/- marker.
+func lineText(line models.DiffLine) string {
+	return strings.TrimPrefix(line.Content, line.Type)
+}
+
+// restyle applies fn to the style of the bytes [start, end) of the text
+// spanned by segs, splitting segments at the edges.
+func restyle(segs []segment, start, end int, fn func(lipgloss.Style) lipgloss.Style) []segment {
+	var out []segment
+	pos := 0
+	for _, seg := range segs {
+		segStart, segEnd := pos, pos+len(seg.text)
+		pos = segEnd
+		if segEnd <= start || segStart >= end {
+			out = append(out, seg)
+			continue
+		}
+		from := max(start, segStart) - segStart
+		to := min(end, segEnd) - segStart
+		if from > 0 {
+			out = append(out, segment{text: seg.text[:from], style: seg.style})
+		}
+		out = append(out, segment{text: seg.text[from:to], style: fn(seg.style)})
+		if to < len(seg.text) {
+			out = append(out, segment{text: seg.text[to:], style: seg.style})
+		}
+	}
+	return out
+}
+
+// markerStyle is the flat style for a line type or moved kind, used for
+// gutters and for content that has no syntax highlighting.
+func markerStyle(lineType string) lipgloss.Style {
+	switch lineType {
+	case "+":
+		return addStyle
+	case "-":
+		return removeStyle
+	case movedFrom:
+		return movedFromStyle
+	case movedTo:
+		return movedToStyle
+	default:
+		return lipgloss.NewStyle()
+	}
+}
+
+func lineBackground(lineType string) lipgloss.TerminalColor {
+	switch lineType {
+	case "+":
+		return addBackground
+	case "-":
+		return removeBackground
+	case movedFrom:
+		return movedFromBackground
+	case movedTo:
+		return movedToBackground
+	default:
+		return lipgloss.NoColor{}
+	}
+}
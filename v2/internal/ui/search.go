@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/charmbracelet/bubbles/textinput"
+
+	"go-diff/internal/models"
+)
+
+type searchScope int
+
+const (
+	scopeAll searchScope = iota
+	scopeAdded
+	scopeRemoved
+)
+
+func (s searchScope) String() string {
+	switch s {
+	case scopeAdded:
+		return "added"
+	case scopeRemoved:
+		return "removed"
+	default:
+		return "all"
+	}
+}
+
+// searchMatch is one regex match inside the content of a diff line.
+type searchMatch struct {
+	file, hunk, line int
+	start, end       int
+}
+
+type lineKey struct {
+	file, hunk, line int
+}
+
+// search holds the state of an incremental regex search across every file
+// in the diff.
+type search struct {
+	input   textinput.Model
+	typing  bool
+	scope   searchScope
+	re      *regexp.Regexp
+	err     error
+	matches []searchMatch
+	byLine  map[lineKey][]int
+	current int
+
+	// version changes whenever the matches or the current match change, so
+	// rendered rows can be cached until then.
+	version int
+}
+
+func newSearch() *search {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	return &search{input: ti, current: -1}
+}
+
+// run recompiles the pattern and collects matches in diff order.
+func (s *search) run(files []models.DiffFile) {
+	s.version++
+	s.re, s.err = nil, nil
+	s.matches, s.byLine = nil, map[lineKey][]int{}
+	s.current = -1
+
+	pattern := s.input.Value()
+	if pattern == "" {
+		return
+	}
+	s.re, s.err = regexp.Compile(pattern)
+	if s.err != nil {
+		return
+	}
+
+	for fi, f := range files {
+		for hi, h := range f.Hunks {
+			for li, line := range h.Lines {
+				if !s.inScope(line) {
+					continue
+				}
+				for _, loc := range s.re.FindAllStringIndex(lineText(line), -1) {
+					if loc[0] == loc[1] {
+						continue
+					}
+					key := lineKey{fi, hi, li}
+					s.byLine[key] = append(s.byLine[key], len(s.matches))
+					s.matches = append(s.matches, searchMatch{fi, hi, li, loc[0], loc[1]})
+				}
+			}
+		}
+	}
+}
+
+func (s *search) inScope(line models.DiffLine) bool {
+	if line.OldNum == 0 && line.NewNum == 0 {
+		return false
+	}
+	switch s.scope {
+	case scopeAdded:
+		return line.Type == "+"
+	case scopeRemoved:
+		return line.Type == "-"
+	default:
+		return true
+	}
+}
+
+func (s *search) lineMatches(file, hunk, line int) []int {
+	return s.byLine[lineKey{file, hunk, line}]
+}
+
+// first selects the first match at or after the given file.
+func (s *search) first(file int) {
+	s.version++
+	s.current = -1
+	for i, match := range s.matches {
+		if match.file >= file {
+			s.current = i
+			return
+		}
+	}
+	if len(s.matches) > 0 {
+		s.current = 0
+	}
+}
+
+// step moves to the next (dir > 0) or previous match, wrapping around.
+func (s *search) step(dir int) {
+	if len(s.matches) == 0 {
+		return
+	}
+	s.version++
+	s.current = (s.current + dir + len(s.matches)) % len(s.matches)
+}
+
+func (s *search) status() string {
+	switch {
+	case s.typing:
+		return fmt.Sprintf("[%s] %s", s.scope, s.input.View())
+	case s.input.Value() == "":
+		return ""
+	case s.err != nil:
+		return fmt.Sprintf("/%s  invalid pattern: %v", s.input.Value(), s.err)
+	case len(s.matches) == 0:
+		return fmt.Sprintf("[%s] /%s  no matches", s.scope, s.input.Value())
+	default:
+		return fmt.Sprintf("[%s] /%s  %d/%d", s.scope, s.input.Value(), s.current+1, len(s.matches))
+	}
+}
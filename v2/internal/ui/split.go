@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// minSplitWidth is the narrowest diff pane that still renders side by side;
+// below it the view falls back to unified.
+const minSplitWidth = 80
+
+const tabWidth = 4
+
+func expandTabs(s string) string {
+	return expandTabsFrom(s, 0)
+}
+
+// expandTabsFrom expands tabs in s as if it started at column col.
+func expandTabsFrom(s string, col int) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(r)
+		col += runewidth.RuneWidth(r)
+	}
+	return b.String()
+}
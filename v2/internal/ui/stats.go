@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"go-diff/internal/stats"
+)
+
+const statBarWidth = 10
+
+// statBar draws a git-style +/- histogram scaled so that the largest file
+// in the diff fills the whole width.
+func statBar(added, removed, scale int) string {
+	if scale <= 0 || added+removed == 0 {
+		return ""
+	}
+	width := statBarWidth
+	if added+removed < scale {
+		width = max(1, (added+removed)*statBarWidth/scale)
+	}
+	plus := added * width / (added + removed)
+	if added > 0 && plus == 0 {
+		plus = 1
+	}
+	minus := width - plus
+	if removed > 0 && minus == 0 && plus > 1 {
+		plus, minus = plus-1, 1
+	}
+	return addStyle.Render(strings.Repeat("+", plus)) + removeStyle.Render(strings.Repeat("-", minus))
+}
+
+func renderSummary(s stats.Summary, width int) string {
+	var b strings.Builder
+
+	b.WriteString(summaryTitleStyle.Render("Diff summary") + "\n\n")
+	fmt.Fprintf(&b, "  %s changed, %s, %s\n",
+		plural(len(s.Files), "file"),
+		addStyle.Render(fmt.Sprintf("%d insertions(+)", s.Added)),
+		removeStyle.Render(fmt.Sprintf("%d deletions(-)", s.Removed)))
+	fmt.Fprintf(&b, "  %d added, %d deleted, %d renamed, %d copied, %d modified\n\n",
+		s.ByStatus["A"], s.ByStatus["D"], s.ByStatus["R"], s.ByStatus["C"], s.ByStatus["M"])
+
+	b.WriteString(summaryTitleStyle.Render("Largest changes") + "\n")
+	for _, f := range s.Largest[:min(len(s.Largest), 10)] {
+		bar := statBar(f.Added, f.Removed, s.MaxChanges())
+		bar += strings.Repeat(" ", statBarWidth-lipgloss.Width(bar))
+		fmt.Fprintf(&b, "  %s %-13s %s  %s\n",
+			f.Status, counts(f.Added, f.Removed), bar, truncate(f.Name, max(10, width-32)))
+	}
+
+	b.WriteString("\n" + summaryTitleStyle.Render("By extension") + "\n")
+	writeGroups(&b, s.ByExtension)
+
+	b.WriteString("\n" + summaryTitleStyle.Render("By top-level directory") + "\n")
+	writeGroups(&b, s.ByDirectory)
+
+	return b.String()
+}
+
+func writeGroups(b *strings.Builder, groups []stats.Group) {
+	scale := 0
+	for _, g := range groups {
+		scale = max(scale, g.Changes())
+	}
+	for _, g := range groups {
+		fmt.Fprintf(b, "  %-16s %10s  %-13s %s\n",
+			truncate(g.Name, 16), plural(g.Files, "file"), counts(g.Added, g.Removed), statBar(g.Added, g.Removed, scale))
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func counts(added, removed int) string {
+	return fmt.Sprintf("+%d -%d", added, removed)
+}
+
+// summaryView is the full-screen diffstat shown instead of the panes.
+func (m model) summaryView() string {
+	style := diffStyle.Width(max(20, m.width-2))
+	content := renderSummary(m.summary, m.width-4)
+	lines := strings.Split(content, "\n")
+	if len(lines) > m.diffHeight() {
+		lines = lines[:m.diffHeight()]
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		style.Render(strings.Join(lines, "\n")),
+		statusStyle.Render("S or esc to return"))
+}
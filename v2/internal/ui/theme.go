@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+
+	"go-diff/internal/theme"
+)
+
+func init() {
+	t, _ := theme.Resolve("dark", nil, true)
+	applyTheme(t)
+}
+
+// applyTheme points every package style at the colors of t.
+func applyTheme(t theme.Theme) {
+	color := func(c string) lipgloss.Color { return lipgloss.Color(c) }
+	fg := func(c string) lipgloss.Style { return lipgloss.NewStyle().Foreground(color(c)) }
+
+	fileListStyle = borderStyle.BorderForeground(color(t.Border))
+	diffStyle = borderStyle.BorderForeground(color(t.Border))
+
+	addStyle = fg(t.Add)
+	removeStyle = fg(t.Remove)
+	headerStyle = fg(t.Header)
+	gutterStyle = fg(t.Gutter)
+	dividerStyle = fg(t.Divider)
+	cursorStyle = fg(t.Cursor)
+	selectionStyle = fg(t.Selection)
+	commentStyle = fg(t.Comment).Italic(true)
+
+	statusStyle = fg(t.Status)
+	summaryTitleStyle = fg(t.Title).Bold(true)
+	focusedBorderColor = color(t.FocusedBorder)
+
+	searchMatchStyle = fg(t.MatchForeground).Background(color(t.SearchMatch))
+	currentMatchStyle = fg(t.MatchForeground).Background(color(t.CurrentMatch))
+
+	addBackground = color(t.AddBackground)
+	removeBackground = color(t.RemoveBackground)
+	addEmphasis = color(t.AddEmphasis)
+	removeEmphasis = color(t.RemoveEmphasis)
+
+	movedFromStyle = fg(t.MovedFrom)
+	movedToStyle = fg(t.MovedTo)
+	movedFromBackground = color(t.MovedFromBackground)
+	movedToBackground = color(t.MovedToBackground)
+
+	syntaxStyle = styles.Get(t.Syntax)
+}
+
+// themeList styles the file list and its items with the colors of t.
+func themeList(l *list.Model, t theme.Theme) {
+	color := func(c string) lipgloss.Color { return lipgloss.Color(c) }
+
+	l.Styles.Title = l.Styles.Title.Background(color(t.ListTitle)).Foreground(color(t.ListTitleText))
+	l.Styles.StatusBar = l.Styles.StatusBar.Foreground(color(t.ListDim))
+	l.Styles.FilterPrompt = l.Styles.FilterPrompt.Foreground(color(t.ListSelected))
+	l.Styles.FilterCursor = l.Styles.FilterCursor.Foreground(color(t.ListSelected))
+
+	d := list.NewDefaultDelegate()
+	d.Styles.NormalTitle = d.Styles.NormalTitle.Foreground(color(t.ListText))
+	d.Styles.NormalDesc = d.Styles.NormalDesc.Foreground(color(t.ListDim))
+	d.Styles.SelectedTitle = d.Styles.SelectedTitle.Foreground(color(t.ListSelected)).BorderForeground(color(t.ListSelected))
+	d.Styles.SelectedDesc = d.Styles.SelectedDesc.Foreground(color(t.ListSelected)).BorderForeground(color(t.ListSelected))
+	d.Styles.DimmedTitle = d.Styles.DimmedTitle.Foreground(color(t.ListDim))
+	d.Styles.DimmedDesc = d.Styles.DimmedDesc.Foreground(color(t.ListDim))
+	l.SetDelegate(d)
+}
@@ -0,0 +1,298 @@
+package ui
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"go-diff/internal/models"
+)
+
+// treeNode is a directory or file in the sidebar tree.
+type treeNode struct {
+	name      string // display name; chains of single directories are joined
+	path      string
+	file      int // index into diffData, -1 for directories
+	children  []*treeNode
+	collapsed bool
+	changed   bool // changed in the last refresh
+	viewed    bool // marked viewed; for directories, every file inside is
+
+	added, removed int
+}
+
+func (n *treeNode) isDir() bool { return n.file < 0 }
+
+// buildTree groups files by directory, directories first, each level sorted
+// by name.
+func buildTree(files []models.DiffFile) *treeNode {
+	root := &treeNode{file: -1}
+	for i, f := range files {
+		added, removed := f.Stats()
+		node := root
+		parts := strings.Split(f.FileName, "/")
+		for j, part := range parts[:len(parts)-1] {
+			node.added += added
+			node.removed += removed
+			node = node.child(part, strings.Join(parts[:j+1], "/"))
+		}
+		node.added += added
+		node.removed += removed
+		node.children = append(node.children, &treeNode{
+			name:    parts[len(parts)-1],
+			path:    f.FileName,
+			file:    i,
+			added:   added,
+			removed: removed,
+		})
+	}
+	root.sort()
+	for _, c := range root.children {
+		c.compact()
+	}
+	return root
+}
+
+func (n *treeNode) child(name, path string) *treeNode {
+	for _, c := range n.children {
+		if c.isDir() && c.name == name {
+			return c
+		}
+	}
+	c := &treeNode{name: name, path: path, file: -1}
+	n.children = append(n.children, c)
+	return c
+}
+
+func (n *treeNode) sort() {
+	sort.SliceStable(n.children, func(i, j int) bool {
+		a, b := n.children[i], n.children[j]
+		if a.isDir() != b.isDir() {
+			return a.isDir()
+		}
+		return a.name < b.name
+	})
+	for _, c := range n.children {
+		c.sort()
+	}
+}
+
+// compact merges directories that only hold a single directory, so that
+// deep paths like internal/ui take one row.
+func (n *treeNode) compact() {
+	for n.isDir() && len(n.children) == 1 && n.children[0].isDir() {
+		only := n.children[0]
+		n.name += "/" + only.name
+		n.path = only.path
+		n.children = only.children
+	}
+	for _, c := range n.children {
+		c.compact()
+	}
+}
+
+// items flattens the visible part of the tree into list items, leaving out
+// viewed files if hideViewed is set.
+func (n *treeNode) items(files []models.DiffFile, scale int, hideViewed bool) []list.Item {
+	var items []list.Item
+	var walk func(*treeNode, int)
+	walk = func(node *treeNode, depth int) {
+		for _, c := range node.children {
+			if hideViewed && c.viewed {
+				continue
+			}
+			items = append(items, listItem{node: c, depth: depth, status: fileStatus(files, c), scale: scale})
+			if c.isDir() && !c.collapsed {
+				walk(c, depth+1)
+			}
+		}
+	}
+	walk(n, 0)
+	return items
+}
+
+// flatItems lists every file regardless of collapsed directories, which is
+// what the fuzzy filter searches.
+func (n *treeNode) flatItems(files []models.DiffFile, scale int, hideViewed bool) []list.Item {
+	var items []list.Item
+	var walk func(*treeNode)
+	walk = func(node *treeNode) {
+		for _, c := range node.children {
+			if hideViewed && c.viewed {
+				continue
+			}
+			if c.isDir() {
+				walk(c)
+				continue
+			}
+			items = append(items, listItem{node: c, flat: true, status: fileStatus(files, c), scale: scale})
+		}
+	}
+	walk(n)
+	return items
+}
+
+// expandTo uncollapses every directory on the way to the given file.
+func (n *treeNode) expandTo(file int) bool {
+	for _, c := range n.children {
+		if c.file == file || (c.isDir() && c.expandTo(file)) {
+			if c.isDir() {
+				c.collapsed = false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// markViewed flags the files viewed reports, and the directories holding
+// only such files.
+func (n *treeNode) markViewed(viewed func(file int) bool) bool {
+	all := true
+	for _, c := range n.children {
+		if c.isDir() {
+			c.viewed = c.markViewed(viewed)
+		} else {
+			c.viewed = viewed(c.file)
+		}
+		all = all && c.viewed
+	}
+	return all
+}
+
+// collapseViewed collapses the directories above file that hold nothing but
+// viewed files.
+func (n *treeNode) collapseViewed(file int) bool {
+	for _, c := range n.children {
+		if c.file == file {
+			return true
+		}
+		if c.isDir() && c.collapseViewed(file) {
+			c.collapsed = c.collapsed || c.viewed
+			return true
+		}
+	}
+	return false
+}
+
+// files lists the files of the tree in display order.
+func (n *treeNode) files() []int {
+	var files []int
+	for _, c := range n.children {
+		if c.isDir() {
+			files = append(files, c.files()...)
+		} else {
+			files = append(files, c.file)
+		}
+	}
+	return files
+}
+
+func (n *treeNode) setCollapsed(collapsed bool) {
+	for _, c := range n.children {
+		if c.isDir() {
+			c.collapsed = collapsed
+			c.setCollapsed(collapsed)
+		}
+	}
+}
+
+// collapsedPaths returns the paths of every collapsed directory.
+func (n *treeNode) collapsedPaths() map[string]bool {
+	paths := map[string]bool{}
+	var walk func(*treeNode)
+	walk = func(node *treeNode) {
+		for _, c := range node.children {
+			if c.isDir() {
+				if c.collapsed {
+					paths[c.path] = true
+				}
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return paths
+}
+
+// restore carries collapsed directories over from a previous tree and flags
+// changed files along with the directories holding them.
+func (n *treeNode) restore(collapsed, changed map[string]bool) bool {
+	for _, c := range n.children {
+		if c.isDir() {
+			c.collapsed = collapsed[c.path]
+			c.changed = c.restore(collapsed, changed)
+		} else {
+			c.changed = changed[c.path]
+		}
+		n.changed = n.changed || c.changed
+	}
+	return n.changed
+}
+
+func (n *treeNode) clearChanged() {
+	n.changed = false
+	for _, c := range n.children {
+		c.clearChanged()
+	}
+}
+
+func fileStatus(files []models.DiffFile, n *treeNode) string {
+	if n.isDir() {
+		return ""
+	}
+	return files[n.file].Status
+}
+
+// changedMarker flags files that changed in the last watch refresh.
+const changedMarker = "↻"
+
+// viewedMarker flags viewed files and fully viewed directories.
+const viewedMarker = "✓"
+
+type listItem struct {
+	node   *treeNode
+	depth  int
+	flat   bool
+	status string
+	scale  int // changes in the largest file, for the stat bar
+}
+
+func (i listItem) Title() string {
+	if i.flat {
+		// The filter highlights matches by position, so the title must be
+		// exactly the filter value.
+		return i.node.path
+	}
+	indent := strings.Repeat("  ", i.depth)
+	if i.node.isDir() {
+		icon := "▾"
+		if i.node.collapsed {
+			icon = "▸"
+		}
+		return indent + icon + " " + i.node.name + "/" + i.viewedSuffix()
+	}
+	return indent + i.status + " " + i.node.name + i.viewedSuffix()
+}
+
+func (i listItem) viewedSuffix() string {
+	if i.node.viewed {
+		return " " + viewedMarker
+	}
+	return ""
+}
+
+func (i listItem) Description() string {
+	indent := strings.Repeat("  ", i.depth)
+	desc := indent + counts(i.node.added, i.node.removed)
+	if !i.node.isDir() {
+		desc += " " + statBar(i.node.added, i.node.removed, i.scale)
+	}
+	if i.node.changed {
+		desc += " " + changedMarker
+	}
+	return desc
+}
+
+func (i listItem) FilterValue() string { return i.node.path }
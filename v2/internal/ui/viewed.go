@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// listTitle heads the file sidebar.
+const listTitle = "Changed Files"
+
+// listItems returns the sidebar items: the tree, or every file while
+// filtering.
+func (m model) listItems() []list.Item {
+	if m.treeFlat {
+		return m.tree.flatItems(m.diffData, m.summary.MaxChanges(), m.hideViewed)
+	}
+	return m.tree.items(m.diffData, m.summary.MaxChanges(), m.hideViewed)
+}
+
+// markViewed flags viewed files in the tree and puts the review progress in
+// the sidebar title.
+func (m *model) markViewed() {
+	if m.viewed == nil {
+		return
+	}
+	count := 0
+	m.tree.markViewed(func(file int) bool {
+		if m.viewed.Has(m.diffData[file]) {
+			count++
+			return true
+		}
+		return false
+	})
+	m.list.Title = fmt.Sprintf("%s %d/%d viewed", listTitle, count, len(m.diffData))
+}
+
+// toggleViewed marks the selected file viewed, collapsing directories that
+// are now fully viewed and moving on to the next unviewed file, or unmarks
+// it.
+func (m model) toggleViewed() (tea.Model, tea.Cmd) {
+	if m.viewed == nil {
+		m.message = "viewed marks are not available outside a git repository"
+		return m, nil
+	}
+	file := m.selectedIndex()
+	if file < 0 {
+		return m, nil
+	}
+	if err := m.viewed.Toggle(m.diffData[file]); err != nil {
+		m.message = "saving viewed files: " + err.Error()
+	}
+	m.markViewed()
+	if !m.viewed.Has(m.diffData[file]) {
+		m.selectFile(file)
+		return m, nil
+	}
+
+	m.tree.collapseViewed(file)
+	next := m.nextUnviewed(file)
+	if next < 0 {
+		m.reselect(m.list.Index())
+		return m, nil
+	}
+	m.selectFile(next)
+	m.offset, m.cursor = 0, 0
+	m.selecting = false
+	return m, nil
+}
+
+// toggleHideViewed shows or hides viewed files in the sidebar.
+func (m model) toggleHideViewed() (tea.Model, tea.Cmd) {
+	m.hideViewed = !m.hideViewed
+	file := m.selectedIndex()
+	index := m.list.Index()
+	cmd := m.list.SetItems(m.listItems())
+	for i, item := range m.list.Items() {
+		if n := item.(listItem).node; !n.isDir() && n.file == file {
+			m.list.Select(i)
+			return m, cmd
+		}
+	}
+	if next := m.nextUnviewed(file); next >= 0 {
+		m.selectFile(next)
+		m.offset, m.cursor = 0, 0
+		return m, cmd
+	}
+	m.reselect(index)
+	return m, cmd
+}
+
+// nextUnviewed returns the first unviewed file after file in the sidebar,
+// wrapping around, or -1 if every file has been viewed.
+func (m model) nextUnviewed(file int) int {
+	files := m.tree.files()
+	start := 0
+	for i, f := range files {
+		if f == file {
+			start = i + 1
+		}
+	}
+	for i := range files {
+		f := files[(start+i)%len(files)]
+		if !m.viewed.Has(m.diffData[f]) {
+			return f
+		}
+	}
+	return -1
+}
+
+// reselect rebuilds the sidebar and selects the item nearest index.
+func (m *model) reselect(index int) {
+	m.list.SetItems(m.listItems())
+	m.list.Select(max(0, min(index, len(m.list.Items())-1)))
+	m.offset, m.cursor = 0, 0
+	m.loadHighlight()
+}
@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go-diff/internal/keymap"
+)
+
+// rowCache keeps the rendered rows of the selected file so that scrolling
+// only slices the visible window instead of re-rendering the whole file.
+type rowCache struct {
+	key  rowCacheKey
+	rows []diffRow
+}
+
+type rowCacheKey struct {
+	generation  int
+	comments    int
+	file        int
+	split       bool
+	width       int
+	search      int
+	highlighted bool
+}
+
+const wheelStep = 3
+
+// cursorColumn is the width of the column left of the diff that marks the
+// cursor and selection.
+const cursorColumn = 1
+
+func (m model) diffRows() []diffRow {
+	idx := m.selectedIndex()
+	if idx < 0 {
+		return nil
+	}
+	f := m.diffData[idx]
+	r := diffRenderer{
+		file:    f,
+		fileIdx: idx,
+		hl:      m.highlights[f.FileName],
+		search:  m.search,
+		moves:   &m.moves,
+		width:   m.diffWidth() - cursorColumn,
+	}
+	split := m.split && r.width >= minSplitWidth
+
+	key := rowCacheKey{
+		generation:  m.generation,
+		comments:    m.commentVersion,
+		file:        idx,
+		split:       split,
+		width:       r.width,
+		search:      m.search.version,
+		highlighted: r.hl != nil,
+	}
+	if m.rows.rows != nil && m.rows.key == key {
+		return m.rows.rows
+	}
+
+	r.notes = m.fileNotes(idx)
+	if split {
+		m.rows.rows = r.split()
+	} else {
+		m.rows.rows = r.unified()
+	}
+	m.rows.key = key
+	return m.rows.rows
+}
+
+// visibleRows returns the window of rows currently on screen.
+func (m model) visibleRows() []diffRow {
+	rows := m.diffRows()
+	start := min(m.offset, len(rows))
+	end := min(len(rows), start+m.diffHeight())
+	return rows[start:end]
+}
+
+func (m model) maxOffset() int {
+	return max(0, len(m.diffRows())-m.diffHeight())
+}
+
+// scrollTo moves the window, dragging the cursor along if it would leave
+// the screen.
+func (m *model) scrollTo(offset int) {
+	m.offset = max(0, min(offset, m.maxOffset()))
+	last := max(0, min(m.offset+m.diffHeight(), len(m.diffRows()))-1)
+	m.cursor = max(m.offset, min(m.cursor, last))
+}
+
+// setCursor moves the cursor to row, scrolling just enough to show it.
+func (m *model) setCursor(row int) {
+	m.cursor = max(0, min(row, len(m.diffRows())-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if h := m.diffHeight(); m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *model) scroll(delta int) {
+	m.scrollTo(m.offset + delta)
+}
+
+// updateScroll moves the diff pane for the scrolling actions. It reports
+// whether action was one of them.
+func (m *model) updateScroll(action keymap.Action) bool {
+	page := m.diffHeight()
+	switch action {
+	case keymap.CursorDown:
+		m.setCursor(m.cursor + 1)
+	case keymap.CursorUp:
+		m.setCursor(m.cursor - 1)
+	case keymap.ScrollDown:
+		m.scroll(1)
+	case keymap.ScrollUp:
+		m.scroll(-1)
+	case keymap.HalfPageDown:
+		m.cursor += page / 2
+		m.scroll(page / 2)
+	case keymap.HalfPageUp:
+		m.cursor -= page / 2
+		m.scroll(-page / 2)
+	case keymap.PageDown:
+		m.cursor += page
+		m.scroll(page)
+	case keymap.PageUp:
+		m.cursor -= page
+		m.scroll(-page)
+	case keymap.Top:
+		m.setCursor(0)
+	case keymap.Bottom:
+		m.setCursor(len(m.diffRows()) - 1)
+	default:
+		return false
+	}
+	return true
+}
+
+func (m *model) updateMouse(msg tea.MouseMsg) {
+	if msg.Action != tea.MouseActionPress {
+		return
+	}
+	switch msg.Button {
+	case tea.MouseButtonWheelDown:
+		m.scroll(wheelStep)
+	case tea.MouseButtonWheelUp:
+		m.scroll(-wheelStep)
+	}
+}
+
+// paneText renders the visible rows behind the cursor column.
+func (m model) paneText() string {
+	rows := m.visibleRows()
+	from, to := m.selection()
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		mark := " "
+		switch n := m.offset + i; {
+		case n == m.cursor:
+			mark = cursorStyle.Render("▌")
+		case n >= from && n <= to:
+			mark = selectionStyle.Render("┃")
+		}
+		lines[i] = mark + row.text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// selection returns the range of rows selected, which is empty (from > to)
+// unless selecting.
+func (m model) selection() (int, int) {
+	if !m.selecting {
+		return 0, -1
+	}
+	return min(m.selectFrom, m.cursor), max(m.selectFrom, m.cursor)
+}
+
+// scrollPosition describes the visible window, e.g. "12-40/300 40%".
+func (m model) scrollPosition() string {
+	total := len(m.diffRows())
+	if total == 0 {
+		return ""
+	}
+	end := min(total, m.offset+m.diffHeight())
+	percent := 100
+	if m.maxOffset() > 0 {
+		percent = m.offset * 100 / m.maxOffset()
+	}
+	return fmt.Sprintf("%d-%d/%d %d%%", m.offset+1, end, total, percent)
+}
@@ -0,0 +1,123 @@
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"go-diff/internal/git"
+)
+
+// debounce is how long things have to stay quiet before a change is
+// reported, so that a save or a git command touching many files causes a
+// single refresh.
+const debounce = 150 * time.Millisecond
+
+var ErrClosed = errors.New("watcher closed")
+
+// Watcher reports changes to a repository's work tree and index.
+type Watcher struct {
+	fs     *fsnotify.Watcher
+	gitDir string
+}
+
+// New watches every directory of the current repository's work tree that
+// is not ignored, plus the git directory for index updates.
+func New() (*Watcher, error) {
+	root, err := git.RepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	gitDir, err := git.GitDir()
+	if err != nil {
+		return nil, err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{fs: fsw, gitDir: gitDir}
+	if err := fsw.Add(gitDir); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// addTree watches dir and the directories below it.
+func (w *Watcher) addTree(dir string) error {
+	var dirs []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+
+	ignored := git.Ignored(dirs)
+	for _, d := range dirs {
+		if ignored[d] {
+			continue
+		}
+		if err := w.fs.Add(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Wait blocks until the work tree or index changes.
+func (w *Watcher) Wait() error {
+	var quiet <-chan time.Time
+	for {
+		select {
+		case ev, ok := <-w.fs.Events:
+			if !ok {
+				return ErrClosed
+			}
+			if w.relevant(ev) {
+				quiet = time.After(debounce)
+			}
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return ErrClosed
+			}
+			return err
+		case <-quiet:
+			return nil
+		}
+	}
+}
+
+func (w *Watcher) relevant(ev fsnotify.Event) bool {
+	if filepath.Dir(ev.Name) == w.gitDir {
+		// The index is replaced by renaming index.lock over it.
+		return filepath.Base(ev.Name) == "index" && ev.Has(fsnotify.Create)
+	}
+	if ev.Op == fsnotify.Chmod {
+		return false
+	}
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			w.addTree(ev.Name)
+		}
+	}
+	return true
+}
+
+func (w *Watcher) Close() error {
+	return w.fs.Close()
+}